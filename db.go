@@ -1,10 +1,19 @@
 package db2go
 
 import (
+	"context"
+	"crypto/tls"
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
 // ConnectionString defines the details required to establish a connection to a database.
@@ -19,8 +28,80 @@ type ConnectionString struct {
 	User string
 	// Password is the password associated with the User for database authentication.
 	Password string
+	// PasswordFile is the path of a file holding the password, read when connecting.
+	// Trailing whitespace and line breaks are removed. It takes precedence over
+	// PasswordEnv and Password.
+	PasswordFile string
+	// PasswordEnv is the name of an environment variable holding the password, read when
+	// connecting. Trailing whitespace and line breaks are removed. It takes precedence
+	// over Password.
+	PasswordEnv string
 	// DatabaseName is the name of the specific database to connect to on the server.
+	// With the SQLite dialect it is the path of the database file instead.
 	DatabaseName string
+	// Dialect selects the database engine. A nil Dialect selects MySQL.
+	Dialect Dialect
+	// TLS enables TLS for MySQL connections through the "tls" DSN parameter: "true"
+	// verifies the server certificate, "preferred" uses TLS only if the server supports
+	// it, and any other value names a configuration registered with
+	// `mysql.RegisterTLSConfig`. "skip-verify" encrypts the connection without verifying
+	// the server certificate, which leaves it open to man-in-the-middle attacks; only use
+	// it for testing. An empty value leaves TLS disabled. With the Postgres dialect the
+	// mode becomes the "sslmode" parameter: "true" maps to "verify-full", "preferred" to
+	// "prefer", "skip-verify" to "require", "false" to "disable", and any other value, e.g.
+	// "verify-ca", is used as is.
+	TLS string
+	// TLSConfig is registered with `mysql.RegisterTLSConfig` under the TLS name before the
+	// connection is opened. When TLS is empty, it is registered under a name unique to the
+	// connection, so connections with different configurations do not replace each
+	// other's. TLS must not be one of the modes "true", "false", "skip-verify" or
+	// "preferred" then. It is only supported by dialects using the "mysql" driver.
+	TLSConfig *tls.Config
+	// Pool configures the connection pool of the returned `*sql.DB`.
+	Pool PoolConfig
+	// Params holds extra DSN parameters, e.g. "charset" or "loc" for MySQL, or
+	// "application_name" for Postgres. Keys and values are escaped, and they override the
+	// parameters set by default or derived from the other fields.
+	Params map[string]string
+}
+
+// PoolConfig holds the connection pool settings applied to a new `*sql.DB`. Zero values
+// leave the corresponding setting at the `database/sql` default.
+type PoolConfig struct {
+	// MaxOpenConns is the maximum number of open connections. See `sql.DB.SetMaxOpenConns`.
+	MaxOpenConns int
+	// MaxIdleConns is the maximum number of idle connections. See `sql.DB.SetMaxIdleConns`.
+	MaxIdleConns int
+	// ConnMaxLifetime is the maximum time a connection may be reused. See
+	// `sql.DB.SetConnMaxLifetime`.
+	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime is the maximum time a connection may stay idle. See
+	// `sql.DB.SetConnMaxIdleTime`.
+	ConnMaxIdleTime time.Duration
+}
+
+// poolSetter is the subset of `*sql.DB` used to configure the connection pool.
+type poolSetter interface {
+	SetMaxOpenConns(n int)
+	SetMaxIdleConns(n int)
+	SetConnMaxLifetime(d time.Duration)
+	SetConnMaxIdleTime(d time.Duration)
+}
+
+// apply sets every non-zero setting of the configuration on db.
+func (p PoolConfig) apply(db poolSetter) {
+	if p.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(p.MaxOpenConns)
+	}
+	if p.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(p.MaxIdleConns)
+	}
+	if p.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(p.ConnMaxLifetime)
+	}
+	if p.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+	}
 }
 
 // TableDescriptor represents the schema details of a single column in a database table.
@@ -37,6 +118,25 @@ type TableDescriptor struct {
 	Default *string
 	// Extra contains additional information about the column, such as auto-increment settings.
 	Extra string
+	// Comment is the comment of the column. It is only populated by `GetSchemaDescriptors`,
+	// since `DESCRIBE` does not report it.
+	Comment string
+	// OrdinalPosition is the 1-based position of the column in the table. It is only
+	// populated by `GetSchemaDescriptors`, and is zero otherwise.
+	OrdinalPosition int
+	// CharacterSet is the character set of a string column, e.g. "utf8mb4". It is only
+	// populated by `GetSchemaDescriptors`, and is empty for other columns.
+	CharacterSet string
+	// Collation is the collation of a string column, e.g. "utf8mb4_general_ci" or "binary".
+	// It is only populated by `GetSchemaDescriptors`, and is empty for other columns.
+	Collation string
+	// View reports that the column belongs to a view rather than a base table. It is only
+	// populated by `GetDescriptorsForAllTablesByType` and `GetSchemaDescriptors`. Structs
+	// generated for views get an `IsView` method and no write statements.
+	View bool
+	// References is the column referenced by a foreign key on this column, as
+	// "table.column". It is only populated by `AddForeignKeys`, and is empty otherwise.
+	References string
 }
 
 // GetDbConnection establishes and returns a connection to a MySQL database.
@@ -55,12 +155,13 @@ type TableDescriptor struct {
 // Behavior:
 //   - The function formats the connection string to include parsing of time values and a timeout.
 //   - If the connection cannot be created or the database cannot be reached, the function
-//     logs the error message and panics.
+//     logs the error message with the package `Logger` and panics. Use `GetDbConnectionE`
+//     to receive the error instead.
 //
 // Notes:
 //   - The caller is responsible for closing the returned connection to avoid resource leaks.
-//   - This function assumes a MySQL database and uses the Go `sql` package along with the
-//     MySQL driver.
+//   - The database engine is selected by `c.Dialect`. When it is nil, a MySQL database is
+//     assumed and the bundled MySQL driver is used.
 //   - Ensure the `ConnectionString` struct contains valid and properly formatted connection parameters.
 //
 // Example Usage:
@@ -76,20 +177,333 @@ type TableDescriptor struct {
 //	db := GetDbConnection(connString)
 func GetDbConnection(c *ConnectionString) *sql.DB {
 
-	dbURI := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true&timeout=%ds", c.User, c.Password, c.Host, c.Port, c.DatabaseName, c.Timeout)
-	conn, err := sql.Open("mysql", dbURI)
-
+	conn, err := GetDbConnectionE(c)
 	if err != nil {
-		fmt.Println("failed creating connection to DB")
+		logger.Println(err)
 		panic(err)
 	}
 
+	return conn
+}
+
+var (
+	// ErrOpenConnection is wrapped by errors returned when the connection cannot be
+	// created, usually because the connection parameters are invalid.
+	ErrOpenConnection = errors.New("failed creating connection to DB")
+	// ErrPingConnection is wrapped by errors returned when the database cannot be
+	// reached or rejects the credentials.
+	ErrPingConnection = errors.New("cannot establish connection with DB")
+)
+
+// GetDbConnectionE establishes and returns a connection to a MySQL database, returning errors.
+//
+// This function behaves like `GetDbConnection` but returns an error instead of panicking,
+// so long-running services can retry or degrade gracefully.
+//
+// Parameters:
+//   - c: *ConnectionString - A pointer to a `ConnectionString` struct containing
+//     the database connection details.
+//
+// Returns:
+//   - *sql.DB: A pointer to an established SQL database connection.
+//   - error: An error wrapping `ErrOpenConnection` if the connection cannot be created, or
+//     `ErrPingConnection` if the database cannot be reached, along with the driver error.
+//
+// Example Usage:
+//
+//	db, err := GetDbConnectionE(connString)
+//	if errors.Is(err, ErrPingConnection) {
+//	    // the server is unreachable or the credentials are wrong
+//	}
+func GetDbConnectionE(c *ConnectionString) (*sql.DB, error) {
+
+	conn, err := openConnection(c)
+	if err != nil {
+		return nil, err
+	}
+
 	if err = conn.Ping(); err != nil {
-		fmt.Println("cannot stablish connection with DB")
-		panic(err)
+		conn.Close()
+		return nil, fmt.Errorf("%w: %w", ErrPingConnection, err)
 	}
 
-	return conn
+	return conn, nil
+}
+
+// GetDbConnectionWithRetry establishes a connection, retrying the ping with exponential
+// backoff until the database is reachable.
+//
+// This function behaves like `GetDbConnectionE`, but a failed ping is retried after
+// waiting `backoff`, then twice as long before each following attempt. It is meant for
+// services starting along with their database, e.g. with docker-compose.
+//
+// Parameters:
+//   - ctx: context.Context - The context bounding the whole operation, including the waits.
+//   - c: *ConnectionString - A pointer to a `ConnectionString` struct containing
+//     the database connection details.
+//   - attempts: int - The maximum number of pings. Values lower than 1 default to 1.
+//   - backoff: time.Duration - The wait before the second attempt.
+//
+// Returns:
+//   - *sql.DB: A pointer to an established SQL database connection.
+//   - error: An error wrapping `ErrOpenConnection` if the connection cannot be created, or
+//     `ErrPingConnection` along with the last ping error once the attempts are exhausted
+//     or the context is done. In the latter case the context error is wrapped too.
+//
+// Example Usage:
+//
+//	db, err := GetDbConnectionWithRetry(ctx, connString, 5, 500*time.Millisecond)
+func GetDbConnectionWithRetry(ctx context.Context, c *ConnectionString, attempts int, backoff time.Duration) (*sql.DB, error) {
+
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	conn, err := openConnection(c)
+	if err != nil {
+		return nil, err
+	}
+
+	wait := backoff
+	for i := 0; ; i++ {
+		err = conn.PingContext(ctx)
+		if err == nil {
+			return conn, nil
+		}
+
+		if i+1 >= attempts {
+			break
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			conn.Close()
+			return nil, fmt.Errorf("%w: %w: %w", ErrPingConnection, ctx.Err(), err)
+		case <-timer.C:
+		}
+		wait *= 2
+	}
+
+	conn.Close()
+	return nil, fmt.Errorf("%w after %d attempts: %w", ErrPingConnection, attempts, err)
+}
+
+// openConnection registers the TLS configuration of a connection, opens it and configures
+// its pool, without checking that the database is reachable.
+func openConnection(c *ConnectionString) (*sql.DB, error) {
+
+	c, err := resolvePassword(c)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpenConnection, err)
+	}
+
+	c, err = registerTLSConfig(c)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpenConnection, err)
+	}
+
+	d := dialectOf(c)
+	conn, err := sql.Open(d.DriverName(), d.DataSourceName(c))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpenConnection, err)
+	}
+
+	c.Pool.apply(conn)
+
+	return conn, nil
+}
+
+// resolvePassword returns a copy of the connection details holding the password read from
+// PasswordFile or PasswordEnv, or the connection details unchanged if neither is set.
+func resolvePassword(c *ConnectionString) (*ConnectionString, error) {
+
+	password := ""
+	switch {
+	case c.PasswordFile != "":
+		content, err := os.ReadFile(c.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading password file: %w", err)
+		}
+		password = string(content)
+	case c.PasswordEnv != "":
+		value, ok := os.LookupEnv(c.PasswordEnv)
+		if !ok {
+			return nil, fmt.Errorf("password environment variable %s is not set", c.PasswordEnv)
+		}
+		password = value
+	default:
+		return c, nil
+	}
+
+	resolved := *c
+	resolved.Password = strings.TrimRight(password, " \t\r\n")
+	return &resolved, nil
+}
+
+// reservedTLSModes lists the TLS modes the MySQL driver interprets itself, which cannot
+// name a registered configuration.
+var reservedTLSModes = map[string]bool{"true": true, "false": true, "skip-verify": true, "preferred": true}
+
+// tlsConfigs counts the configurations registered under a generated name.
+var tlsConfigs atomic.Uint64
+
+// registerTLSConfig registers the TLSConfig of a connection with the MySQL driver and
+// returns a copy of the connection details whose TLS field names it, or the connection
+// details unchanged if TLSConfig is nil.
+func registerTLSConfig(c *ConnectionString) (*ConnectionString, error) {
+
+	if c.TLSConfig == nil {
+		return c, nil
+	}
+
+	if d := dialectOf(c); d.DriverName() != MySQL.DriverName() {
+		return nil, fmt.Errorf("TLSConfig is not supported by the %s driver", d.DriverName())
+	}
+	if reservedTLSModes[c.TLS] {
+		return nil, fmt.Errorf("TLS mode %q cannot name a TLSConfig", c.TLS)
+	}
+
+	resolved := *c
+	if resolved.TLS == "" {
+		resolved.TLS = fmt.Sprintf("db2go-%d", tlsConfigs.Add(1))
+	}
+	if err := mysql.RegisterTLSConfig(resolved.TLS, resolved.TLSConfig); err != nil {
+		return nil, err
+	}
+
+	return &resolved, nil
+}
+
+// ConnectionOption configures a single setting of a `ConnectionString` used by `NewConnection`.
+type ConnectionOption func(*ConnectionString)
+
+// WithHost sets the hostname or IP address of the database server.
+func WithHost(host string) ConnectionOption {
+	return func(c *ConnectionString) { c.Host = host }
+}
+
+// WithPort sets the port number on which the database server is listening.
+func WithPort(port uint16) ConnectionOption {
+	return func(c *ConnectionString) { c.Port = port }
+}
+
+// WithTimeout sets the connection timeout, in seconds.
+func WithTimeout(timeout uint16) ConnectionOption {
+	return func(c *ConnectionString) { c.Timeout = timeout }
+}
+
+// WithCredentials sets the user and password used to authenticate.
+func WithCredentials(user, password string) ConnectionOption {
+	return func(c *ConnectionString) {
+		c.User = user
+		c.Password = password
+	}
+}
+
+// WithPasswordFile reads the password from a file when connecting, e.g. a Docker or
+// Kubernetes secret. See `ConnectionString.PasswordFile`.
+func WithPasswordFile(path string) ConnectionOption {
+	return func(c *ConnectionString) { c.PasswordFile = path }
+}
+
+// WithPasswordEnv reads the password from an environment variable when connecting. See
+// `ConnectionString.PasswordEnv`.
+func WithPasswordEnv(name string) ConnectionOption {
+	return func(c *ConnectionString) { c.PasswordEnv = name }
+}
+
+// WithDatabaseName sets the database to connect to.
+func WithDatabaseName(name string) ConnectionOption {
+	return func(c *ConnectionString) { c.DatabaseName = name }
+}
+
+// WithDialect sets the database engine, e.g. `Postgres`. MySQL is used when not set.
+func WithDialect(d Dialect) ConnectionOption {
+	return func(c *ConnectionString) { c.Dialect = d }
+}
+
+// WithPool sets the connection pool settings applied to the returned connection.
+func WithPool(pool PoolConfig) ConnectionOption {
+	return func(c *ConnectionString) { c.Pool = pool }
+}
+
+// WithParam sets an extra DSN parameter, e.g. `WithParam("charset", "utf8mb4")`.
+func WithParam(key, value string) ConnectionOption {
+	return func(c *ConnectionString) {
+		if c.Params == nil {
+			c.Params = make(map[string]string)
+		}
+		c.Params[key] = value
+	}
+}
+
+// WithTLS enables TLS for MySQL connections. The mode is "true", "preferred",
+// "skip-verify" or the name under which cfg is registered; see `ConnectionString.TLS`.
+// A nil cfg uses the configuration of the mode. A non-nil cfg is registered under the
+// mode, or under a name unique to the connection if the mode is empty, and opening the
+// connection fails if the mode is one of the built-in ones.
+func WithTLS(mode string, cfg *tls.Config) ConnectionOption {
+	return func(c *ConnectionString) {
+		c.TLS = mode
+		c.TLSConfig = cfg
+	}
+}
+
+// WithSQLiteFile selects the SQLite dialect and the path of the database file to open.
+func WithSQLiteFile(path string) ConnectionOption {
+	return func(c *ConnectionString) {
+		c.Dialect = SQLite
+		c.DatabaseName = path
+	}
+}
+
+// NewConnection establishes a connection to a MySQL database configured by functional options.
+//
+// This function starts from a `ConnectionString` with the default MySQL port (3306) and a
+// 10 second timeout, applies every option in order, and then opens and pings the
+// database. Unlike `GetDbConnection` it returns errors instead of panicking.
+//
+// Parameters:
+//   - opts: ...ConnectionOption - The options to apply, such as `WithHost` or `WithPort`.
+//
+// Returns:
+//   - *sql.DB: A pointer to an established SQL database connection.
+//   - error: An error if the connection cannot be created or the database cannot be reached.
+//
+// Example Usage:
+//
+//	db, err := NewConnection(
+//	    WithHost("localhost"),
+//	    WithCredentials("root", "password"),
+//	    WithDatabaseName("my_database"),
+//	)
+func NewConnection(opts ...ConnectionOption) (*sql.DB, error) {
+
+	c := &ConnectionString{
+		Port:    3306,
+		Timeout: 10,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return GetDbConnectionE(c)
+}
+
+// QuoteIdentifier quotes a MySQL identifier such as a table or column name.
+//
+// The identifier is wrapped in backticks and any embedded backtick is doubled, so
+// reserved words (e.g. `order`), names with special characters (e.g. `user-logs`) and
+// hostile names can be safely interpolated into a query.
+//
+// Example Usage:
+//   - QuoteIdentifier("order")     -> "`order`"
+//   - QuoteIdentifier("user-logs") -> "`user-logs`"
+func QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
 }
 
 // GetTableDescriptor retrieves the column descriptors for a specified table.
@@ -110,33 +524,35 @@ func GetDbConnection(c *ConnectionString) *sql.DB {
 // Notes:
 //   - This function will panic if there is an error executing the query or scanning
 //     the rows. Ensure proper error handling and valid table names are used before
-//     calling this function.
+//     calling this function, or use `GetTableDescriptorContext` to receive the error.
 func GetTableDescriptor(conn *sql.DB, tableName string) []TableDescriptor {
 
-	rows, err := conn.Query(fmt.Sprintf("describe %s", tableName))
+	result, err := GetTableDescriptorContext(context.Background(), conn, tableName)
 	if err != nil {
-		fmt.Println("failed querying table description")
+		logger.Println(err)
 		panic(err)
 	}
 
-	defer rows.Close()
-
-	result := make([]TableDescriptor, 0)
-	for rows.Next() {
-		r := TableDescriptor{}
-
-		err = rows.Scan(&r.Field, &r.Type, &r.Null, &r.Key, &r.Default, &r.Extra)
-		if err != nil {
-			fmt.Println("failed scanning table description row")
-			panic(err)
-		}
-
-		result = append(result, r)
-	}
-
 	return result
 }
 
+// GetTableDescriptorContext retrieves the column descriptors for a specified table,
+// aborting when the context is done.
+//
+// Parameters:
+//   - ctx: context.Context - The context controlling cancellation and deadlines of the query.
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - tableName: string - The name of the table to describe.
+//
+// Returns:
+//   - []TableDescriptor: A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the specified table.
+//   - error: An error if the query fails or is cancelled. Cancellation errors wrap
+//     `context.Canceled` or `context.DeadlineExceeded`.
+func GetTableDescriptorContext(ctx context.Context, conn *sql.DB, tableName string) ([]TableDescriptor, error) {
+	return GetTableDescriptorDialect(ctx, conn, MySQL, tableName)
+}
+
 // GetDescriptorsForAllTables retrieves table descriptors for all tables in a database.
 //
 // This function queries the database connection `conn` to get the names of all tables
@@ -153,17 +569,356 @@ func GetTableDescriptor(conn *sql.DB, tableName string) []TableDescriptor {
 //     and the value is a slice of `TableDescriptor` containing metadata for the respective table.
 func GetDescriptorsForAllTables(conn *sql.DB) map[string][]TableDescriptor {
 
-	tables := GetDbTableNames(conn)
+	result, err := GetDescriptorsForAllTablesContext(context.Background(), conn)
+	if err != nil {
+		logger.Println(err)
+		panic(err)
+	}
+
+	return result
+}
+
+// GetDescriptorsForAllTablesContext retrieves table descriptors for all tables in a database,
+// aborting when the context is done.
+//
+// Parameters:
+//   - ctx: context.Context - The context controlling cancellation and deadlines of the queries.
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//
+// Returns:
+//   - map[string][]TableDescriptor: A map where the key is the table name (string)
+//     and the value is a slice of `TableDescriptor` containing metadata for the respective table.
+//   - error: An error if any query fails or is cancelled.
+func GetDescriptorsForAllTablesContext(ctx context.Context, conn *sql.DB) (map[string][]TableDescriptor, error) {
+	return GetDescriptorsForAllTablesDialect(ctx, conn, MySQL)
+}
+
+// GetDescriptorsForAllTablesByType retrieves table descriptors for the base tables of a
+// database, and optionally for its views.
+//
+// This function behaves like `GetDescriptorsForAllTables`, listing the tables with
+// `GetDbTableNamesByType` so views can be left out. Views are described like tables,
+// with `View` set on every column so their structs are generated as read-only.
+//
+// Parameters:
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - includeViews: bool - If `true`, views are described along with the base tables.
+//
+// Returns:
+//   - map[string][]TableDescriptor: A map where the key is the table name (string)
+//     and the value is a slice of `TableDescriptor` containing metadata for the respective table.
+//   - error: An error if any query fails.
+//
+// Notes:
+//   - This function only works with MySQL and MariaDB; see `GetDbTableNamesByType`.
+func GetDescriptorsForAllTablesByType(conn *sql.DB, includeViews bool) (map[string][]TableDescriptor, error) {
+	return GetDescriptorsForAllTablesByTypeContext(context.Background(), conn, includeViews)
+}
+
+// GetDescriptorsForAllTablesByTypeContext behaves like `GetDescriptorsForAllTablesByType`,
+// aborting when the context is done.
+func GetDescriptorsForAllTablesByTypeContext(ctx context.Context, conn *sql.DB, includeViews bool) (map[string][]TableDescriptor, error) {
+
+	tables, views, err := listTablesByType(ctx, conn, includeViews)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := describeTables(ctx, conn, MySQL, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	for t := range views {
+		for i := range result[t] {
+			result[t][i].View = true
+		}
+	}
+
+	return result, nil
+}
+
+// GetDescriptorsForTables retrieves table descriptors for an explicit list of tables.
+//
+// Unlike `GetDescriptorsForAllTables`, this function does not list the tables of the
+// database and only describes the named ones.
+//
+// Parameters:
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - tableNames: []string - The names of the tables to describe.
+//
+// Returns:
+//   - map[string][]TableDescriptor: A map where the key is the table name (string)
+//     and the value is a slice of `TableDescriptor` containing metadata for the respective table.
+//   - error: An error naming every table that does not exist, or an error if any query fails.
+//
+// Notes:
+//   - The tables are looked up in MySQL's `information_schema.TABLES` and described with
+//     "DESCRIBE". Use `GetDescriptorsForTablesDialect` for other databases.
+func GetDescriptorsForTables(conn *sql.DB, tableNames []string) (map[string][]TableDescriptor, error) {
+	return GetDescriptorsForTablesContext(context.Background(), conn, tableNames)
+}
+
+// GetDescriptorsForTablesContext behaves like `GetDescriptorsForTables`, aborting when the
+// context is done.
+func GetDescriptorsForTablesContext(ctx context.Context, conn *sql.DB, tableNames []string) (map[string][]TableDescriptor, error) {
+
+	if len(tableNames) == 0 {
+		return make(map[string][]TableDescriptor), nil
+	}
+
+	args := make([]interface{}, 0, len(tableNames))
+	for _, t := range tableNames {
+		args = append(args, t)
+	}
+
+	query := "select TABLE_NAME from information_schema.TABLES where TABLE_SCHEMA = database() and TABLE_NAME in (?" +
+		strings.Repeat(", ?", len(args)-1) + ")"
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed querying tables: %w", err)
+	}
+
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		name := ""
+		if err = rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed scanning table name row: %w", err)
+		}
+		existing[name] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading table names: %w", err)
+	}
+
+	if err := checkTablesExist(tableNames, existing); err != nil {
+		return nil, err
+	}
+
+	return describeTables(ctx, conn, MySQL, tableNames)
+}
+
+// checkTablesExist returns an error naming every table of tableNames missing from existing.
+func checkTablesExist(tableNames []string, existing map[string]bool) error {
+
+	missing := make([]string, 0)
+	for _, t := range tableNames {
+		if !existing[t] {
+			missing = append(missing, t)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("tables not found: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// TableFilter selects tables by name with regular expressions.
+//
+// A table matches when it matches Include, or Include is nil, and it does not match
+// Exclude. Exclude therefore wins when a table matches both.
+type TableFilter struct {
+	// Include selects the tables to keep. A nil Include keeps every table.
+	Include *regexp.Regexp
+	// Exclude selects the tables to drop. A nil Exclude drops none.
+	Exclude *regexp.Regexp
+}
+
+// Match reports whether a table name is selected by the filter.
+func (f TableFilter) Match(tableName string) bool {
+	if f.Exclude != nil && f.Exclude.MatchString(tableName) {
+		return false
+	}
+	return f.Include == nil || f.Include.MatchString(tableName)
+}
+
+// GetDescriptorsForAllTablesFiltered retrieves table descriptors for the tables of a database
+// selected by a filter.
+//
+// This function lists the tables like `GetDescriptorsForAllTables`, but only describes
+// those matching the filter, which avoids describing irrelevant tables of large databases.
+//
+// Parameters:
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - filter: TableFilter - The filter applied to the raw table names.
+//
+// Returns:
+//   - map[string][]TableDescriptor: A map where the key is the table name (string)
+//     and the value is a slice of `TableDescriptor` containing metadata for the respective table.
+//   - error: An error if any query fails.
+//
+// Example Usage:
+//
+//	filter := TableFilter{Include: regexp.MustCompile("^user"), Exclude: regexp.MustCompile("_logs$")}
+//	descriptors, err := GetDescriptorsForAllTablesFiltered(conn, filter)
+//
+// Notes:
+//   - The tables are introspected with the `MySQL` dialect. Use
+//     `GetDescriptorsForAllTablesFilteredDialect` for other databases.
+func GetDescriptorsForAllTablesFiltered(conn *sql.DB, filter TableFilter) (map[string][]TableDescriptor, error) {
+	return GetDescriptorsForAllTablesFilteredContext(context.Background(), conn, filter)
+}
+
+// GetDescriptorsForAllTablesFilteredContext behaves like `GetDescriptorsForAllTablesFiltered`,
+// aborting when the context is done.
+func GetDescriptorsForAllTablesFilteredContext(ctx context.Context, conn *sql.DB, filter TableFilter) (map[string][]TableDescriptor, error) {
+	return GetDescriptorsForAllTablesFilteredDialect(ctx, conn, MySQL, filter)
+}
+
+// GetDescriptorsForAllTablesParallel retrieves table descriptors for all tables using concurrent queries.
+//
+// This function lists the tables like `GetDescriptorsForAllTables` and then describes
+// them from a bounded pool of goroutines, which hides network latency on remote
+// databases. The first failure cancels the remaining work.
+//
+// Parameters:
+//   - ctx: context.Context - The context controlling cancellation and deadlines of the queries.
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - concurrency: int - The maximum number of concurrent "DESCRIBE" queries. Values lower
+//     than 1 default to `runtime.NumCPU()`.
+//
+// Returns:
+//   - map[string][]TableDescriptor: A map where the key is the table name (string)
+//     and the value is a slice of `TableDescriptor` containing metadata for the respective table.
+//   - error: The first error returned by a query, or the context error if it was cancelled.
+//
+// Notes:
+//   - The connection pool of `conn` should allow at least `concurrency` open connections,
+//     otherwise workers wait for a free connection.
+//   - The tables are introspected with the `MySQL` dialect. Use
+//     `GetDescriptorsForAllTablesParallelDialect` for other databases.
+func GetDescriptorsForAllTablesParallel(ctx context.Context, conn *sql.DB, concurrency int) (map[string][]TableDescriptor, error) {
+	return GetDescriptorsForAllTablesParallelDialect(ctx, conn, MySQL, concurrency)
+}
+
+// GetSchemaDescriptors retrieves table descriptors for all tables in a database with a single query.
+//
+// This function produces the same map as `GetDescriptorsForAllTables`, but instead of
+// one "SHOW TABLES" plus one "DESCRIBE" per table it reads `information_schema.COLUMNS`
+// for the current database once and groups the rows in memory. On remote databases with
+// many tables this avoids N+1 round-trips.
+//
+// Parameters:
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//
+// Returns:
+//   - map[string][]TableDescriptor: A map where the key is the table name (string)
+//     and the value is a slice of `TableDescriptor` containing metadata for the respective table.
+//   - error: An error if the query fails.
+//
+// Notes:
+//   - Columns are ordered by their `ORDINAL_POSITION`, so field order is stable and matches
+//     the order used by `SELECT *`. The order is enforced in memory as well, so it does not
+//     depend on the server honoring the ORDER BY clause.
+//   - Unlike `DESCRIBE`, this query also reports `Comment`, `OrdinalPosition`,
+//     `CharacterSet` and `Collation`, and sets `View` on the columns of views.
+func GetSchemaDescriptors(conn *sql.DB) (map[string][]TableDescriptor, error) {
+	return GetSchemaDescriptorsContext(context.Background(), conn)
+}
+
+// GetSchemaDescriptorsContext behaves like `GetSchemaDescriptors`, aborting when the context is done.
+func GetSchemaDescriptorsContext(ctx context.Context, conn *sql.DB) (map[string][]TableDescriptor, error) {
+
+	rows, err := conn.QueryContext(ctx, "select c.TABLE_NAME, c.COLUMN_NAME, c.COLUMN_TYPE, c.IS_NULLABLE, c.COLUMN_KEY, c.COLUMN_DEFAULT, c.EXTRA, "+
+		"c.COLUMN_COMMENT, c.ORDINAL_POSITION, c.CHARACTER_SET_NAME, c.COLLATION_NAME, t.TABLE_TYPE from information_schema.COLUMNS c "+
+		"join information_schema.TABLES t on t.TABLE_SCHEMA = c.TABLE_SCHEMA and t.TABLE_NAME = c.TABLE_NAME "+
+		"where c.TABLE_SCHEMA = database() order by c.TABLE_NAME, c.ORDINAL_POSITION")
+	if err != nil {
+		return nil, fmt.Errorf("failed querying schema columns: %w", err)
+	}
+
+	defer rows.Close()
 
 	result := make(map[string][]TableDescriptor)
+	for rows.Next() {
+		table := ""
+		r := TableDescriptor{}
+		characterSet := sql.NullString{}
+		collation := sql.NullString{}
+		tableType := ""
 
-	for _, t := range tables {
+		err = rows.Scan(&table, &r.Field, &r.Type, &r.Null, &r.Key, &r.Default, &r.Extra, &r.Comment,
+			&r.OrdinalPosition, &characterSet, &collation, &tableType)
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning schema column row: %w", err)
+		}
+		r.CharacterSet = characterSet.String
+		r.Collation = collation.String
+		r.View = tableType == "VIEW"
 
-		result[t] = GetTableDescriptor(conn, t)
+		result[table] = append(result[table], r)
+	}
 
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading schema columns: %w", err)
 	}
 
-	return result
+	for _, tt := range result {
+		sort.SliceStable(tt, func(i, j int) bool {
+			return tt[i].OrdinalPosition < tt[j].OrdinalPosition
+		})
+	}
+
+	return result, nil
+}
+
+// AddForeignKeys fills the `References` field of the columns that have a foreign key.
+//
+// Neither `DESCRIBE` nor `information_schema.COLUMNS` reports foreign keys, so this
+// function reads `information_schema.KEY_COLUMN_USAGE` for the current database once and
+// sets `References` on the matching columns of the given descriptors.
+//
+// Parameters:
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - descriptors: map[string][]TableDescriptor - The table descriptors to update, as
+//     returned by `GetDescriptorsForAllTables` or `GetSchemaDescriptors`. They are
+//     modified in place.
+//
+// Returns:
+//   - error: An error if the query fails.
+//
+// Notes:
+//   - Foreign keys on tables or columns that are not in `descriptors` are ignored.
+//   - `FieldOrderKeysFirst` relies on `References` to find foreign key columns, so call
+//     this function before generating code with that ordering.
+func AddForeignKeys(conn *sql.DB, descriptors map[string][]TableDescriptor) error {
+	return AddForeignKeysContext(context.Background(), conn, descriptors)
+}
+
+// AddForeignKeysContext behaves like `AddForeignKeys`, aborting when the context is done.
+func AddForeignKeysContext(ctx context.Context, conn *sql.DB, descriptors map[string][]TableDescriptor) error {
+
+	rows, err := conn.QueryContext(ctx, "select TABLE_NAME, COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME "+
+		"from information_schema.KEY_COLUMN_USAGE where TABLE_SCHEMA = database() and REFERENCED_TABLE_NAME is not null")
+	if err != nil {
+		return fmt.Errorf("failed querying foreign keys: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		table, column, refTable, refColumn := "", "", "", ""
+		if err = rows.Scan(&table, &column, &refTable, &refColumn); err != nil {
+			return fmt.Errorf("failed scanning foreign key row: %w", err)
+		}
+		tt := descriptors[table]
+		for i := range tt {
+			if tt[i].Field == column {
+				tt[i].References = refTable + "." + refColumn
+			}
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed reading foreign keys: %w", err)
+	}
+
+	return nil
 }
 
 // GetDbTableNames retrieves the names of all tables in the connected database.
@@ -180,28 +935,351 @@ func GetDescriptorsForAllTables(conn *sql.DB) map[string][]TableDescriptor {
 //
 // Notes:
 //   - This function will panic if there is an error executing the query or scanning
-//     the rows. Ensure error handling and proper database connection setup before calling this function.
+//     the rows. Ensure error handling and proper database connection setup before calling
+//     this function, or use `GetDbTableNamesContext` to receive the error.
 func GetDbTableNames(conn *sql.DB) []string {
-	rows, err := conn.Query("show tables")
+
+	result, err := GetDbTableNamesContext(context.Background(), conn)
 	if err != nil {
-		fmt.Println("failed querying tables")
+		logger.Println(err)
 		panic(err)
 	}
 
+	return result
+}
+
+// GetDbTableNamesContext retrieves the names of all tables in the connected database,
+// aborting when the context is done.
+//
+// Parameters:
+//   - ctx: context.Context - The context controlling cancellation and deadlines of the query.
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//
+// Returns:
+//   - []string: A slice containing the names of all tables in the database.
+//   - error: An error if the query fails or is cancelled.
+func GetDbTableNamesContext(ctx context.Context, conn *sql.DB) ([]string, error) {
+	return GetDbTableNamesDialect(ctx, conn, MySQL)
+}
+
+// GetDbTableNamesByType retrieves the names of the base tables of the connected database,
+// and optionally of its views.
+//
+// `SHOW TABLES`, used by `GetDbTableNames`, lists views along with base tables. This
+// function runs `SHOW FULL TABLES` instead, which also reports the type of each entry.
+//
+// Parameters:
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - includeViews: bool - If `true`, views are listed along with the base tables.
+//
+// Returns:
+//   - []string: The names of the matching tables, in the order reported by the database.
+//   - error: An error if the query fails.
+//
+// Notes:
+//   - `SHOW FULL TABLES` only exists in MySQL and MariaDB, so this function, like
+//     `GetDescriptorsForAllTablesByType`, has no dialect variant.
+func GetDbTableNamesByType(conn *sql.DB, includeViews bool) ([]string, error) {
+	return GetDbTableNamesByTypeContext(context.Background(), conn, includeViews)
+}
+
+// GetDbTableNamesByTypeContext behaves like `GetDbTableNamesByType`, aborting when the
+// context is done.
+func GetDbTableNamesByTypeContext(ctx context.Context, conn *sql.DB, includeViews bool) ([]string, error) {
+	result, _, err := listTablesByType(ctx, conn, includeViews)
+	return result, err
+}
+
+// listTablesByType implements `GetDbTableNamesByTypeContext`, also returning the set of
+// listed names that are views.
+func listTablesByType(ctx context.Context, conn *sql.DB, includeViews bool) ([]string, map[string]bool, error) {
+
+	rows, err := conn.QueryContext(ctx, "show full tables")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed querying tables: %w", err)
+	}
+
 	defer rows.Close()
 
 	result := make([]string, 0)
+	views := make(map[string]bool)
 	for rows.Next() {
-		r := ""
+		name, tableType := "", ""
 
-		err = rows.Scan(&r)
+		err = rows.Scan(&name, &tableType)
 		if err != nil {
-			fmt.Println("failed scanning table name row")
-			panic(err)
+			return nil, nil, fmt.Errorf("failed scanning table name row: %w", err)
+		}
+
+		switch {
+		case tableType == "BASE TABLE":
+			result = append(result, name)
+		case includeViews && tableType == "VIEW":
+			result = append(result, name)
+			views[name] = true
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed reading table names: %w", err)
+	}
+
+	return result, views, nil
+}
+
+// GetSchemaVersion reads the current migration version from a migrations table.
+//
+// This function targets the conventional `schema_migrations` table maintained by
+// tools like golang-migrate, which stores a single row with a `version` column.
+// It first checks whether the table exists in the current database, so generation
+// tooling can record or verify the schema version its models correspond to even
+// when no migrations have been applied yet.
+//
+// Parameters:
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - tableName: string - The name of the migrations table (usually "schema_migrations").
+//
+// Returns:
+//   - string: The current version, or an empty string if none is recorded.
+//   - bool: `true` if a version was found, `false` if the table is missing or empty.
+//   - error: An error if querying the database fails.
+//
+// Notes:
+//   - Unlike the other introspection functions, this one returns errors instead of panicking.
+func GetSchemaVersion(conn *sql.DB, tableName string) (string, bool, error) {
+	return GetSchemaVersionContext(context.Background(), conn, tableName)
+}
+
+// GetSchemaVersionContext behaves like `GetSchemaVersion`, aborting when the context is done.
+func GetSchemaVersionContext(ctx context.Context, conn *sql.DB, tableName string) (string, bool, error) {
+
+	exists := 0
+	err := conn.QueryRowContext(ctx, "select count(*) from information_schema.TABLES where TABLE_SCHEMA = database() and TABLE_NAME = ?", tableName).Scan(&exists)
+	if err != nil {
+		return "", false, fmt.Errorf("failed checking migrations table %s: %w", tableName, err)
+	}
+
+	if exists == 0 {
+		return "", false, nil
+	}
+
+	version := ""
+	err = conn.QueryRowContext(ctx, fmt.Sprintf("select version from %s limit 1", QuoteIdentifier(tableName))).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed reading version from %s: %w", tableName, err)
+	}
+
+	return version, true, nil
+}
+
+// DescribeQuery retrieves column descriptors for the result set of an arbitrary query.
+//
+// This function runs the query and inspects `rows.ColumnTypes()` to build one
+// `TableDescriptor` per result column, so aggregate or grouped queries (e.g. `COUNT(*)`,
+// `SUM(amount)`) can be turned into a struct with `CreateStruct` just like a table.
+// Field names are taken from the column aliases.
+//
+// Parameters:
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - query: string - The query whose result shape should be described.
+//   - args: ...interface{} - Optional arguments for placeholders in the query.
+//
+// Returns:
+//   - []TableDescriptor: A slice with the metadata of each result column, in select order.
+//   - error: An error if the query cannot be executed or its column types cannot be read.
+//
+// Notes:
+//   - Columns that are not valid identifiers, such as an unaliased `COUNT(*)`, get a
+//     placeholder name `column_N` where N is the 1-based position of the column.
+//   - Only the column metadata is read; the rows themselves are discarded. Adding a
+//     `LIMIT 0` to expensive queries avoids computing results that are never used.
+func DescribeQuery(conn *sql.DB, query string, args ...interface{}) ([]TableDescriptor, error) {
+	return DescribeQueryContext(context.Background(), conn, query, args...)
+}
+
+// DescribeQueryContext behaves like `DescribeQuery`, aborting when the context is done.
+func DescribeQueryContext(ctx context.Context, conn *sql.DB, query string, args ...interface{}) ([]TableDescriptor, error) {
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed executing query to describe: %w", err)
+	}
+
+	defer rows.Close()
+
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed reading query column types: %w", err)
+	}
+
+	result := make([]TableDescriptor, 0, len(columns))
+	for i, c := range columns {
+		r := TableDescriptor{
+			Field: c.Name(),
+			Type:  c.DatabaseTypeName(),
+			Null:  "YES",
+		}
+
+		if !isIdentifier(r.Field) {
+			r.Field = fmt.Sprintf("column_%d", i+1)
+		}
+
+		if nullable, ok := c.Nullable(); ok && !nullable {
+			r.Null = "NO"
 		}
 
 		result = append(result, r)
 	}
 
-	return result
+	return result, nil
+}
+
+// VerifyColumnCounts checks that tables still have the number of columns they had at generation time.
+//
+// This function compares the expected column count of each table, typically taken from
+// the constants emitted by `CreateColumnCountConst`, with the live schema. It is a cheap
+// drift guard meant to fail fast at startup when a column was added or removed without
+// regenerating the models.
+//
+// Parameters:
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - expected: map[string]int - A map from table name to its expected column count.
+//
+// Returns:
+//   - error: `nil` if every table matches, otherwise an error listing each mismatching
+//     table with its expected and actual counts, or the error returned by the database.
+//
+// Notes:
+//   - A table without columns in `information_schema.COLUMNS` does not exist, and is
+//     listed as missing instead of as having zero columns.
+func VerifyColumnCounts(conn *sql.DB, expected map[string]int) error {
+	return VerifyColumnCountsContext(context.Background(), conn, expected)
+}
+
+// VerifyColumnCountsContext behaves like `VerifyColumnCounts`, aborting when the context is done.
+func VerifyColumnCountsContext(ctx context.Context, conn *sql.DB, expected map[string]int) error {
+
+	tables := make([]string, 0, len(expected))
+	for t := range expected {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+
+	mismatches := make([]string, 0)
+	for _, t := range tables {
+		actual := 0
+		err := conn.QueryRowContext(ctx, "select count(*) from information_schema.COLUMNS where TABLE_SCHEMA = database() and TABLE_NAME = ?", t).Scan(&actual)
+		if err != nil {
+			return fmt.Errorf("failed counting columns of %s: %w", t, err)
+		}
+		switch {
+		case actual == 0:
+			mismatches = append(mismatches, fmt.Sprintf("%s: table does not exist", t))
+		case actual != expected[t]:
+			mismatches = append(mismatches, fmt.Sprintf("%s: expected %d columns, found %d", t, expected[t], actual))
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("column count mismatch (%s)", strings.Join(mismatches, "; "))
+	}
+
+	return nil
+}
+
+// FindEmptyNullableColumns reports the nullable columns of a table that contain no NULL values.
+//
+// This function is meant to help tighten a schema: each returned column could be made
+// `NOT NULL` without a data migration. It runs one `COUNT(*) ... IS NULL` query per
+// nullable column.
+//
+// Parameters:
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - tableName: string - The name of the table to analyze.
+//
+// Returns:
+//   - []string: The nullable columns without NULL values, in ordinal order.
+//   - error: An error if querying the database fails.
+//
+// Notes:
+//   - Each count scans the table unless the column is indexed; use
+//     `FindEmptyNullableColumnsLimit` to skip large tables.
+func FindEmptyNullableColumns(conn *sql.DB, tableName string) ([]string, error) {
+	return FindEmptyNullableColumnsLimitContext(context.Background(), conn, tableName, 0)
+}
+
+// FindEmptyNullableColumnsContext behaves like `FindEmptyNullableColumns`, aborting when the context is done.
+func FindEmptyNullableColumnsContext(ctx context.Context, conn *sql.DB, tableName string) ([]string, error) {
+	return FindEmptyNullableColumnsLimitContext(ctx, conn, tableName, 0)
+}
+
+// FindEmptyNullableColumnsLimit behaves like `FindEmptyNullableColumns` but skips large tables.
+//
+// Parameters:
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - tableName: string - The name of the table to analyze.
+//   - maxRows: int64 - The maximum estimated row count to analyze. A value of 0 disables
+//     the limit.
+//
+// Returns:
+//   - []string: The nullable columns without NULL values, in ordinal order.
+//   - error: An error if querying the database fails or if the table has more than
+//     `maxRows` rows.
+//
+// Notes:
+//   - The row count is the estimate from `information_schema.TABLES`, which is cheap to
+//     read but may be inaccurate for InnoDB tables.
+func FindEmptyNullableColumnsLimit(conn *sql.DB, tableName string, maxRows int64) ([]string, error) {
+	return FindEmptyNullableColumnsLimitContext(context.Background(), conn, tableName, maxRows)
+}
+
+// FindEmptyNullableColumnsLimitContext behaves like `FindEmptyNullableColumnsLimit`, aborting when the context is done.
+func FindEmptyNullableColumnsLimitContext(ctx context.Context, conn *sql.DB, tableName string, maxRows int64) ([]string, error) {
+
+	if maxRows > 0 {
+		var rowCount sql.NullInt64
+		err := conn.QueryRowContext(ctx, "select TABLE_ROWS from information_schema.TABLES where TABLE_SCHEMA = database() and TABLE_NAME = ?", tableName).Scan(&rowCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading row count of %s: %w", tableName, err)
+		}
+		if rowCount.Int64 > maxRows {
+			return nil, fmt.Errorf("table %s has about %d rows, more than the limit of %d", tableName, rowCount.Int64, maxRows)
+		}
+	}
+
+	rows, err := conn.QueryContext(ctx, "select COLUMN_NAME from information_schema.COLUMNS where TABLE_SCHEMA = database() and TABLE_NAME = ? and IS_NULLABLE = 'YES' order by ORDINAL_POSITION", tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed querying nullable columns of %s: %w", tableName, err)
+	}
+
+	defer rows.Close()
+
+	columns := make([]string, 0)
+	for rows.Next() {
+		c := ""
+		if err := rows.Scan(&c); err != nil {
+			return nil, fmt.Errorf("failed scanning nullable column of %s: %w", tableName, err)
+		}
+		columns = append(columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading nullable columns of %s: %w", tableName, err)
+	}
+
+	result := make([]string, 0)
+	for _, c := range columns {
+		nulls := 0
+		err := conn.QueryRowContext(ctx, fmt.Sprintf("select count(*) from %s where %s is null", QuoteIdentifier(tableName), QuoteIdentifier(c))).Scan(&nulls)
+		if err != nil {
+			return nil, fmt.Errorf("failed counting nulls of %s.%s: %w", tableName, c, err)
+		}
+		if nulls == 0 {
+			result = append(result, c)
+		}
+	}
+
+	return result, nil
 }
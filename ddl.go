@@ -0,0 +1,264 @@
+package db2go
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DDLDialect is implemented by the dialects able to recreate a table from its descriptors.
+//
+// The `MySQL` and `SQLite` dialects implement it. Other dialects can implement it to be
+// used with `CreateTableDDL`, `ApplySchema` and `OpenTestDatabase`.
+type DDLDialect interface {
+	Dialect
+	// CreateTableDDL returns the CREATE TABLE statement of a table.
+	CreateTableDDL(tt []TableDescriptor, tableName string) string
+}
+
+// CreateTableDDL generates the CREATE TABLE statement of a table for a dialect.
+//
+// The statement declares every column with its type, nullability, default value and
+// auto-increment flag, followed by the primary key, so that a fresh database gets the
+// same tables as the one the descriptors were read from.
+//
+// Parameters:
+//   - tt: []TableDescriptor - A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the table.
+//   - tableName: string - The name of the table to create.
+//   - d: Dialect - The dialect of the target database. It must implement `DDLDialect`.
+//
+// Returns:
+//   - string: The CREATE TABLE statement, without a trailing semicolon.
+//   - error: An error if the dialect cannot generate DDL.
+//
+// Notes:
+//   - Indexes other than the primary key, foreign keys and table options are not part of
+//     the descriptors, so they are not recreated.
+//   - Column types are written as reported by the source database. With `SQLite`, they
+//     are reduced to their base type, and ENUM, SET and JSON columns become TEXT, which
+//     keeps the type affinity `SQLite.MapType` relies on.
+func CreateTableDDL(tt []TableDescriptor, tableName string, d Dialect) (string, error) {
+
+	ddl, ok := d.(DDLDialect)
+	if !ok {
+		return "", fmt.Errorf("dialect %s cannot generate DDL", d.DriverName())
+	}
+
+	return ddl.CreateTableDDL(tt, tableName), nil
+}
+
+// ApplySchema creates the tables of the given descriptors in a database.
+//
+// Parameters:
+//   - ctx: context.Context - The context controlling cancellation and deadlines of the statements.
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - d: Dialect - The dialect of the database behind `conn`. It must implement `DDLDialect`.
+//   - descriptors: map[string][]TableDescriptor - The table descriptors, as returned by
+//     `GetDescriptorsForAllTables` or `GetSchemaDescriptors`.
+//
+// Returns:
+//   - error: An error if the dialect cannot generate DDL, or naming the first table that
+//     could not be created.
+//
+// Notes:
+//   - Tables are created in table name order. Views, whose columns are marked with `View`,
+//     are skipped since their definition is not part of the descriptors.
+func ApplySchema(ctx context.Context, conn *sql.DB, d Dialect, descriptors map[string][]TableDescriptor) error {
+
+	for _, tableName := range sortedTableNames(descriptors) {
+		tt := descriptors[tableName]
+		if len(tt) > 0 && tt[0].View {
+			continue
+		}
+
+		ddl, err := CreateTableDDL(tt, tableName, d)
+		if err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, ddl); err != nil {
+			return fmt.Errorf("failed creating table %s: %w", tableName, err)
+		}
+	}
+
+	return nil
+}
+
+// OpenTestDatabase opens a database and creates the tables of the given descriptors in it.
+//
+// This function is meant for integration tests: the descriptors captured from the real
+// database are applied to a fresh one, e.g. a temporary SQLite file or an ephemeral MySQL
+// server, so tests run against a schema-accurate database built from the same source
+// of truth as the generated models.
+//
+// Parameters:
+//   - ctx: context.Context - The context controlling cancellation and deadlines of the statements.
+//   - c: *ConnectionString - The connection details of the target database. Its `Dialect`
+//     selects the DDL flavor and must implement `DDLDialect`; it defaults to `MySQL`.
+//   - descriptors: map[string][]TableDescriptor - The table descriptors to apply.
+//
+// Returns:
+//   - *sql.DB: A pointer to the open database, holding the tables.
+//   - error: An error wrapping `ErrOpenConnection` if the connection cannot be created, or
+//     the error returned by `ApplySchema`. The connection is closed on error.
+//
+// Example Usage:
+//
+//	db, err := db2go.OpenTestDatabase(ctx, &db2go.ConnectionString{
+//	    Dialect:      db2go.SQLite,
+//	    DatabaseName: filepath.Join(t.TempDir(), "test.db"),
+//	}, descriptors)
+//
+// Notes:
+//   - The target database must be empty, since tables are created without `IF NOT EXISTS`.
+//   - The driver of the dialect must be imported by the caller, as for `GetDbConnectionE`.
+func OpenTestDatabase(ctx context.Context, c *ConnectionString, descriptors map[string][]TableDescriptor) (*sql.DB, error) {
+
+	conn, err := openConnection(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ApplySchema(ctx, conn, dialectOf(c), descriptors); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// temporalDefault matches the temporal keywords MySQL accepts as a default without
+// parentheses, optionally with a precision.
+var temporalDefault = regexp.MustCompile(`(?i)^(?:CURRENT_TIMESTAMP|CURRENT_DATE|CURRENT_TIME|LOCALTIME|LOCALTIMESTAMP|NOW)(?:\(\d*\))?$`)
+
+// defaultClause returns the DEFAULT clause of a column, or an empty string if it has no
+// default. Literal values are quoted with `quote`, and expressions other than the
+// temporal keywords are parenthesized.
+func defaultClause(t TableDescriptor, quote func(string) string) string {
+
+	if t.Default == nil {
+		return ""
+	}
+
+	value := strings.TrimSpace(*t.Default)
+	switch {
+	case !isExpressionDefault(t):
+		value = quote(*t.Default)
+	case temporalDefault.MatchString(value), strings.HasPrefix(value, "("):
+	default:
+		value = "(" + value + ")"
+	}
+
+	return " DEFAULT " + value
+}
+
+// primaryKeyClause returns the PRIMARY KEY clause of a table, or an empty string if the
+// table has no primary key.
+func primaryKeyClause(tt []TableDescriptor, quote func(string) string) string {
+
+	keys := make([]string, 0)
+	for _, key := range PrimaryKeys(tt) {
+		keys = append(keys, quote(key))
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+
+	return "PRIMARY KEY (" + strings.Join(keys, ", ") + ")"
+}
+
+// createTable joins the column definitions and the primary key of a table in a
+// CREATE TABLE statement.
+func createTable(quotedName string, definitions []string, primaryKey string) string {
+
+	if primaryKey != "" {
+		definitions = append(definitions, primaryKey)
+	}
+
+	return "CREATE TABLE " + quotedName + " (\n\t" + strings.Join(definitions, ",\n\t") + "\n)"
+}
+
+// onUpdate matches the ON UPDATE clause MySQL reports in the Extra column.
+var onUpdate = regexp.MustCompile(`(?i)\bon update (\S+)`)
+
+func (mysqlDialect) CreateTableDDL(tt []TableDescriptor, tableName string) string {
+
+	quote := func(v string) string {
+		return "'" + strings.ReplaceAll(strings.ReplaceAll(v, `\`, `\\`), "'", "''") + "'"
+	}
+
+	definitions := make([]string, 0, len(tt))
+	for _, t := range tt {
+		definition := QuoteIdentifier(t.Field) + " " + t.Type
+		if t.Null == "NO" || t.Key == "PRI" {
+			definition += " NOT NULL"
+		}
+		definition += defaultClause(t, quote)
+		if IsAutoIncrement(t) {
+			definition += " AUTO_INCREMENT"
+		}
+		if m := onUpdate.FindStringSubmatch(t.Extra); m != nil {
+			definition += " ON UPDATE " + m[1]
+		}
+		definitions = append(definitions, definition)
+	}
+
+	return createTable(QuoteIdentifier(tableName), definitions, primaryKeyClause(tt, QuoteIdentifier))
+}
+
+// sqliteIdentifier quotes an identifier for SQLite, doubling embedded double quotes.
+func sqliteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// sqliteType returns the SQLite declared type of a column read from another database.
+func sqliteType(t TableDescriptor) string {
+
+	switch base := baseType(t.Type); base {
+	case "ENUM", "SET", "JSON":
+		return "TEXT"
+	case "":
+		return "BLOB"
+	default:
+		return base
+	}
+}
+
+func (sqliteDialect) CreateTableDDL(tt []TableDescriptor, tableName string) string {
+
+	quote := func(v string) string {
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	}
+
+	// An auto-increment primary key must be declared inline as INTEGER PRIMARY KEY, which
+	// makes it an alias of the rowid
+	rowid := false
+	if keys := PrimaryKeys(tt); len(keys) == 1 {
+		for _, t := range tt {
+			rowid = rowid || (t.Field == keys[0] && IsAutoIncrement(t))
+		}
+	}
+
+	definitions := make([]string, 0, len(tt))
+	for _, t := range tt {
+		if rowid && t.Key == "PRI" {
+			definitions = append(definitions, sqliteIdentifier(t.Field)+" INTEGER PRIMARY KEY AUTOINCREMENT")
+			continue
+		}
+		definition := sqliteIdentifier(t.Field) + " " + sqliteType(t)
+		if t.Null == "NO" || t.Key == "PRI" {
+			definition += " NOT NULL"
+		}
+		definition += defaultClause(t, quote)
+		definitions = append(definitions, definition)
+	}
+
+	primaryKey := ""
+	if !rowid {
+		primaryKey = primaryKeyClause(tt, sqliteIdentifier)
+	}
+
+	return createTable(sqliteIdentifier(tableName), definitions, primaryKey)
+}
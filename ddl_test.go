@@ -0,0 +1,209 @@
+package db2go
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ddlTables returns descriptors covering keys, defaults and types that need translating.
+func ddlTables() map[string][]TableDescriptor {
+
+	pending := "pending"
+	zero := "0"
+	now := "CURRENT_TIMESTAMP"
+	uuid := "uuid()"
+	quoted := `it's \ here`
+
+	return map[string][]TableDescriptor{
+		"users": {
+			{Field: "id", Type: "bigint unsigned", Null: "NO", Key: "PRI", Extra: "auto_increment"},
+			{Field: "status", Type: "enum('pending','active')", Null: "NO", Default: &pending},
+			{Field: "logins", Type: "int", Null: "NO", Default: &zero},
+			{Field: "note", Type: "varchar(64)", Null: "YES", Default: &quoted},
+			{Field: "updated_at", Type: "datetime", Null: "NO", Default: &now, Extra: "DEFAULT_GENERATED on update CURRENT_TIMESTAMP"},
+		},
+		"order_lines": {
+			{Field: "order_id", Type: "bigint", Null: "NO", Key: "PRI"},
+			{Field: "line", Type: "int", Null: "NO", Key: "PRI"},
+			{Field: "token", Type: "char(36)", Null: "NO", Default: &uuid, Extra: "DEFAULT_GENERATED"},
+			{Field: "attributes", Type: "json", Null: "YES"},
+		},
+		"active_users": {
+			{Field: "id", Type: "bigint unsigned", Null: "NO", View: true},
+		},
+	}
+}
+
+func TestCreateTableDDL(t *testing.T) {
+
+	tables := ddlTables()
+
+	tests := []struct {
+		name    string
+		dialect Dialect
+		table   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "mysql",
+			dialect: MySQL,
+			table:   "users",
+			want: "CREATE TABLE `users` (\n" +
+				"\t`id` bigint unsigned NOT NULL AUTO_INCREMENT,\n" +
+				"\t`status` enum('pending','active') NOT NULL DEFAULT 'pending',\n" +
+				"\t`logins` int NOT NULL DEFAULT '0',\n" +
+				"\t`note` varchar(64) DEFAULT 'it''s \\\\ here',\n" +
+				"\t`updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+				"\tPRIMARY KEY (`id`)\n" +
+				")",
+		},
+		{
+			name:    "mysql composite key and expression default",
+			dialect: MySQL,
+			table:   "order_lines",
+			want: "CREATE TABLE `order_lines` (\n" +
+				"\t`order_id` bigint NOT NULL,\n" +
+				"\t`line` int NOT NULL,\n" +
+				"\t`token` char(36) NOT NULL DEFAULT (uuid()),\n" +
+				"\t`attributes` json,\n" +
+				"\tPRIMARY KEY (`order_id`, `line`)\n" +
+				")",
+		},
+		{
+			name:    "sqlite",
+			dialect: SQLite,
+			table:   "users",
+			want: "CREATE TABLE \"users\" (\n" +
+				"\t\"id\" INTEGER PRIMARY KEY AUTOINCREMENT,\n" +
+				"\t\"status\" TEXT NOT NULL DEFAULT 'pending',\n" +
+				"\t\"logins\" INT NOT NULL DEFAULT '0',\n" +
+				"\t\"note\" VARCHAR DEFAULT 'it''s \\ here',\n" +
+				"\t\"updated_at\" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP\n" +
+				")",
+		},
+		{
+			name:    "sqlite composite key",
+			dialect: SQLite,
+			table:   "order_lines",
+			want: "CREATE TABLE \"order_lines\" (\n" +
+				"\t\"order_id\" BIGINT NOT NULL,\n" +
+				"\t\"line\" INT NOT NULL,\n" +
+				"\t\"token\" CHAR NOT NULL DEFAULT (uuid()),\n" +
+				"\t\"attributes\" TEXT,\n" +
+				"\tPRIMARY KEY (\"order_id\", \"line\")\n" +
+				")",
+		},
+		{
+			name:    "unsupported dialect",
+			dialect: Postgres,
+			table:   "users",
+			wantErr: "dialect postgres cannot generate DDL",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := CreateTableDDL(tables[tc.table], tc.table, tc.dialect)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Errorf("CreateTableDDL() = %v, want %s", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateTableDDL() = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("CreateTableDDL() =\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSQLiteType(t *testing.T) {
+
+	// The declared type must keep the Go type SQLite.MapType gives the recreated column
+	tests := []struct {
+		column TableDescriptor
+		want   string
+		goType string
+	}{
+		{column: TableDescriptor{Type: "bigint unsigned", Null: "NO"}, want: "BIGINT", goType: "int64"},
+		{column: TableDescriptor{Type: "tinyint(1)", Null: "NO"}, want: "TINYINT", goType: "int64"},
+		{column: TableDescriptor{Type: "varchar(64)", Null: "YES"}, want: "VARCHAR", goType: "*string"},
+		{column: TableDescriptor{Type: "enum('a','b')", Null: "NO"}, want: "TEXT", goType: "string"},
+		{column: TableDescriptor{Type: "set('a','b')", Null: "NO"}, want: "TEXT", goType: "string"},
+		{column: TableDescriptor{Type: "json", Null: "YES"}, want: "TEXT", goType: "*string"},
+		{column: TableDescriptor{Type: "datetime(3)", Null: "NO"}, want: "DATETIME", goType: "time.Time"},
+		{column: TableDescriptor{Type: "decimal(10,2)", Null: "NO"}, want: "DECIMAL", goType: "float64"},
+		{column: TableDescriptor{Type: "double", Null: "NO"}, want: "DOUBLE", goType: "float64"},
+		{column: TableDescriptor{Type: "longblob", Null: "YES"}, want: "LONGBLOB", goType: "[]byte"},
+		{column: TableDescriptor{Type: "", Null: "YES"}, want: "BLOB", goType: "[]byte"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.column.Type, func(t *testing.T) {
+			got := sqliteType(tc.column)
+			if got != tc.want {
+				t.Errorf("sqliteType() = %s, want %s", got, tc.want)
+			}
+			recreated := tc.column
+			recreated.Type = got
+			if goType := SQLite.MapType(recreated); goType != tc.goType {
+				t.Errorf("SQLite.MapType(%s) = %s, want %s", got, goType, tc.goType)
+			}
+		})
+	}
+}
+
+func TestOpenTestDatabase(t *testing.T) {
+
+	db := &stubDB{handler: func(string, []driver.NamedValue) (*stubRows, error) {
+		return &stubRows{}, nil
+	}}
+
+	conn, err := OpenTestDatabase(context.Background(), db.register(t), ddlTables())
+	if err != nil {
+		t.Fatalf("OpenTestDatabase() = %v", err)
+	}
+	defer conn.Close()
+
+	tables := ddlTables()
+	want := []string{
+		MySQL.(DDLDialect).CreateTableDDL(tables["order_lines"], "order_lines"),
+		MySQL.(DDLDialect).CreateTableDDL(tables["users"], "users"),
+	}
+	if got := db.executed(); !reflect.DeepEqual(got, want) {
+		t.Errorf("OpenTestDatabase() ran %q, want the tables in name order without the view", got)
+	}
+
+	t.Run("failing statement", func(t *testing.T) {
+		denied := errors.New("Error 1050: Table 'order_lines' already exists")
+		failing := &stubDB{handler: func(string, []driver.NamedValue) (*stubRows, error) {
+			return nil, denied
+		}}
+		_, err := OpenTestDatabase(context.Background(), failing.register(t), ddlTables())
+		if !errors.Is(err, denied) || !strings.Contains(err.Error(), "order_lines") {
+			t.Errorf("OpenTestDatabase() = %v, want an error naming order_lines and wrapping %v", err, denied)
+		}
+	})
+
+	t.Run("missing driver", func(t *testing.T) {
+		_, err := OpenTestDatabase(context.Background(), &ConnectionString{Dialect: missingDriverDialect{}}, ddlTables())
+		if !errors.Is(err, ErrOpenConnection) {
+			t.Errorf("OpenTestDatabase() = %v, want %v", err, ErrOpenConnection)
+		}
+	})
+
+	t.Run("unsupported dialect", func(t *testing.T) {
+		err := ApplySchema(context.Background(), db.open(t), Postgres, ddlTables())
+		if err == nil || !strings.Contains(err.Error(), "cannot generate DDL") {
+			t.Errorf("ApplySchema() = %v, want an unsupported dialect error", err)
+		}
+	})
+}
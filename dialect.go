@@ -0,0 +1,530 @@
+package db2go
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Dialect describes how to connect to and introspect a specific database engine.
+//
+// The package ships with the `MySQL` dialect, used by default everywhere, and the
+// `Postgres` and `SQLite` dialects. Other engines can be supported by implementing this interface.
+type Dialect interface {
+	// DriverName returns the `database/sql` driver name used to open connections.
+	DriverName() string
+	// DataSourceName formats the driver specific DSN for the connection details.
+	DataSourceName(c *ConnectionString) string
+	// TableNamesQuery returns a query listing the tables of the current database, one name per row.
+	TableNamesQuery() string
+	// ColumnsQuery returns a query, and its arguments, listing the columns of a table in
+	// order as rows of Field, Type, Null, Key, Default and Extra.
+	ColumnsQuery(tableName string) (string, []interface{})
+	// MapType returns the Go type used for a column.
+	MapType(t TableDescriptor) string
+}
+
+var (
+	// MySQL is the default dialect, introspecting with "SHOW TABLES" and "DESCRIBE".
+	MySQL Dialect = mysqlDialect{}
+	// Postgres introspects PostgreSQL through information_schema. It requires a driver
+	// registered as "postgres", such as github.com/lib/pq, to be imported by the caller.
+	Postgres Dialect = postgresDialect{}
+	// SQLite introspects SQLite database files through `PRAGMA table_info`. It requires a
+	// driver registered as "sqlite3", such as github.com/mattn/go-sqlite3, to be imported
+	// by the caller. The file path is taken from `ConnectionString.DatabaseName`.
+	SQLite Dialect = sqliteDialect{}
+)
+
+// dialectOf returns the dialect of a connection, defaulting to MySQL.
+func dialectOf(c *ConnectionString) Dialect {
+	if c.Dialect == nil {
+		return MySQL
+	}
+	return c.Dialect
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) DriverName() string {
+	return "mysql"
+}
+
+func (mysqlDialect) DataSourceName(c *ConnectionString) string {
+
+	params := map[string]string{
+		"parseTime": "true",
+		"timeout":   fmt.Sprintf("%ds", c.Timeout),
+	}
+	if c.TLS != "" {
+		params["tls"] = c.TLS
+	}
+	for k, v := range c.Params {
+		params[k] = v
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	query := make([]string, 0, len(keys))
+	for _, k := range keys {
+		query = append(query, url.QueryEscape(k)+"="+url.QueryEscape(params[k]))
+	}
+
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?%s", c.User, c.Password, c.Host, c.Port, c.DatabaseName, strings.Join(query, "&"))
+}
+
+func (mysqlDialect) TableNamesQuery() string {
+	return "show tables"
+}
+
+func (mysqlDialect) ColumnsQuery(tableName string) (string, []interface{}) {
+	return "describe " + QuoteIdentifier(tableName), nil
+}
+
+func (mysqlDialect) MapType(t TableDescriptor) string {
+	return getType(t)
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) DriverName() string {
+	return "postgres"
+}
+
+// postgresSSLModes maps the MySQL style TLS modes of a connection to PostgreSQL sslmode
+// values. Other modes, e.g. "verify-ca", are passed through as the sslmode.
+var postgresSSLModes = map[string]string{
+	"true":        "verify-full",
+	"preferred":   "prefer",
+	"skip-verify": "require",
+	"false":       "disable",
+}
+
+// DataSourceName formats a key/value connection string, with the keys sorted so the DSN
+// is stable. The TLS mode of the connection is mapped to "sslmode" and Params override
+// every other key.
+func (postgresDialect) DataSourceName(c *ConnectionString) string {
+
+	params := map[string]string{
+		"host":            c.Host,
+		"port":            strconv.Itoa(int(c.Port)),
+		"user":            c.User,
+		"password":        c.Password,
+		"dbname":          c.DatabaseName,
+		"connect_timeout": strconv.Itoa(int(c.Timeout)),
+	}
+	if c.TLS != "" {
+		params["sslmode"] = c.TLS
+		if mode, ok := postgresSSLModes[c.TLS]; ok {
+			params["sslmode"] = mode
+		}
+	}
+	for k, v := range c.Params {
+		params[k] = v
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"='"+strings.ReplaceAll(strings.ReplaceAll(params[k], `\`, `\\`), "'", `\'`)+"'")
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+func (postgresDialect) TableNamesQuery() string {
+	return "select table_name from information_schema.tables where table_schema = current_schema() order by table_name"
+}
+
+func (postgresDialect) ColumnsQuery(tableName string) (string, []interface{}) {
+	query := `select c.column_name, c.data_type, c.is_nullable,
+	case when exists (
+		select 1 from information_schema.table_constraints tc
+		join information_schema.key_column_usage k
+			on k.constraint_name = tc.constraint_name and k.table_schema = tc.table_schema and k.table_name = tc.table_name
+		where tc.constraint_type = 'PRIMARY KEY' and k.table_schema = c.table_schema
+			and k.table_name = c.table_name and k.column_name = c.column_name
+	) then 'PRI' else '' end,
+	c.column_default,
+	case when c.is_identity = 'YES' or c.column_default like 'nextval(%' then 'auto_increment' else '' end
+from information_schema.columns c
+where c.table_schema = current_schema() and c.table_name = $1
+order by c.ordinal_position`
+	return query, []interface{}{tableName}
+}
+
+// MapType maps PostgreSQL types, as reported by information_schema or by their
+// internal names (e.g. `int4`, `timestamptz`), to Go types.
+func (postgresDialect) MapType(t TableDescriptor) string {
+
+	cleanType := strings.ToLower(strings.TrimSpace(t.Type))
+	if pos := strings.Index(cleanType, "("); pos > 0 {
+		cleanType = strings.TrimSpace(cleanType[0:pos])
+	}
+
+	result := strings.Builder{}
+	if isNullable(t) {
+		result.WriteString("*")
+	}
+
+	switch cleanType {
+	case "text", "character varying", "varchar", "character", "char", "bpchar", "uuid", "citext":
+		result.WriteString("string")
+	case "bigint", "int8", "bigserial", "serial8":
+		result.WriteString("int64")
+	case "integer", "int", "int4", "serial", "serial4":
+		result.WriteString("int32")
+	case "smallint", "int2", "smallserial", "serial2":
+		result.WriteString("int16")
+	case "numeric", "decimal", "real", "float4", "double precision", "float8":
+		result.WriteString("float64")
+	case "timestamp with time zone", "timestamptz", "timestamp without time zone", "timestamp",
+		"date", "time without time zone", "time", "time with time zone", "timetz":
+		result.WriteString("time.Time")
+	case "bytea":
+		result.Reset()
+		result.WriteString("[]byte")
+	case "boolean", "bool":
+		result.WriteString("bool")
+	case "json", "jsonb":
+		result.WriteString("json.RawMessage")
+	default:
+		result.Reset()
+		result.WriteString("interface{}") // If the type is not known returns generic interface
+	}
+	return result.String()
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) DriverName() string {
+	return "sqlite3"
+}
+
+func (sqliteDialect) DataSourceName(c *ConnectionString) string {
+	return c.DatabaseName
+}
+
+func (sqliteDialect) TableNamesQuery() string {
+	return "select name from sqlite_master where type = 'table' and name not like 'sqlite_%' order by name"
+}
+
+func (sqliteDialect) ColumnsQuery(tableName string) (string, []interface{}) {
+	query := `select name, type,
+	case when "notnull" = 1 then 'NO' else 'YES' end,
+	case when pk > 0 then 'PRI' else '' end,
+	dflt_value, ''
+from pragma_table_info(?)
+order by cid`
+	return query, []interface{}{tableName}
+}
+
+// MapType maps SQLite declared types to Go types following SQLite's type affinity
+// rules, so loosely declared types like `VARCHAR(20)` or `BIGINT` map as expected.
+func (sqliteDialect) MapType(t TableDescriptor) string {
+
+	cleanType := strings.ToUpper(strings.TrimSpace(t.Type))
+
+	result := strings.Builder{}
+	if isNullable(t) {
+		result.WriteString("*")
+	}
+
+	switch {
+	case strings.Contains(cleanType, "INT"):
+		result.WriteString("int64")
+	case strings.Contains(cleanType, "CHAR"), strings.Contains(cleanType, "CLOB"), strings.Contains(cleanType, "TEXT"):
+		result.WriteString("string")
+	case cleanType == "", strings.Contains(cleanType, "BLOB"):
+		result.Reset()
+		result.WriteString("[]byte")
+	case strings.Contains(cleanType, "REAL"), strings.Contains(cleanType, "FLOA"), strings.Contains(cleanType, "DOUB"):
+		result.WriteString("float64")
+	case strings.HasPrefix(cleanType, "BOOL"):
+		result.WriteString("bool")
+	case strings.HasPrefix(cleanType, "DATE"), strings.HasPrefix(cleanType, "TIMESTAMP"):
+		result.WriteString("time.Time")
+	case strings.HasPrefix(cleanType, "NUMERIC"), strings.HasPrefix(cleanType, "DECIMAL"):
+		result.WriteString("float64")
+	default:
+		result.Reset()
+		result.WriteString("interface{}") // If the type is not known returns generic interface
+	}
+	return result.String()
+}
+
+// GetTableDescriptorDialect retrieves the column descriptors for a specified table
+// using the introspection query of a dialect.
+//
+// Parameters:
+//   - ctx: context.Context - The context controlling cancellation and deadlines of the query.
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - d: Dialect - The dialect of the database behind `conn`.
+//   - tableName: string - The name of the table to describe.
+//
+// Returns:
+//   - []TableDescriptor: A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the specified table.
+//   - error: An error if the query fails or is cancelled.
+func GetTableDescriptorDialect(ctx context.Context, conn *sql.DB, d Dialect, tableName string) ([]TableDescriptor, error) {
+
+	query, args := d.ColumnsQuery(tableName)
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed querying table description: %w", err)
+	}
+
+	defer rows.Close()
+
+	result := make([]TableDescriptor, 0)
+	for rows.Next() {
+		r := TableDescriptor{}
+
+		err = rows.Scan(&r.Field, &r.Type, &r.Null, &r.Key, &r.Default, &r.Extra)
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning table description row: %w", err)
+		}
+
+		result = append(result, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading table description: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetDbTableNamesDialect retrieves the names of all tables in the connected database
+// using the introspection query of a dialect.
+//
+// Parameters:
+//   - ctx: context.Context - The context controlling cancellation and deadlines of the query.
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - d: Dialect - The dialect of the database behind `conn`.
+//
+// Returns:
+//   - []string: A slice containing the names of all tables in the database.
+//   - error: An error if the query fails or is cancelled.
+func GetDbTableNamesDialect(ctx context.Context, conn *sql.DB, d Dialect) ([]string, error) {
+
+	rows, err := conn.QueryContext(ctx, d.TableNamesQuery())
+	if err != nil {
+		return nil, fmt.Errorf("failed querying tables: %w", err)
+	}
+
+	defer rows.Close()
+
+	result := make([]string, 0)
+	for rows.Next() {
+		r := ""
+
+		err = rows.Scan(&r)
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning table name row: %w", err)
+		}
+
+		result = append(result, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading table names: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetDescriptorsForAllTablesDialect retrieves table descriptors for all tables in a database
+// using the introspection queries of a dialect.
+//
+// Parameters:
+//   - ctx: context.Context - The context controlling cancellation and deadlines of the queries.
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - d: Dialect - The dialect of the database behind `conn`.
+//
+// Returns:
+//   - map[string][]TableDescriptor: A map where the key is the table name (string)
+//     and the value is a slice of `TableDescriptor` containing metadata for the respective table.
+//   - error: An error if any query fails or is cancelled.
+func GetDescriptorsForAllTablesDialect(ctx context.Context, conn *sql.DB, d Dialect) (map[string][]TableDescriptor, error) {
+
+	tables, err := GetDbTableNamesDialect(ctx, conn, d)
+	if err != nil {
+		return nil, err
+	}
+
+	return describeTables(ctx, conn, d, tables)
+}
+
+// GetDescriptorsForTablesDialect retrieves the table descriptors of an explicit list of
+// tables using the introspection queries of a dialect.
+//
+// Parameters:
+//   - ctx: context.Context - The context controlling cancellation and deadlines of the queries.
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - d: Dialect - The dialect of the database behind `conn`.
+//   - tableNames: []string - The names of the tables to describe.
+//
+// Returns:
+//   - map[string][]TableDescriptor: A map where the key is the table name (string)
+//     and the value is a slice of `TableDescriptor` containing metadata for the respective table.
+//   - error: An error naming every table that does not exist, or an error if any query fails.
+//
+// Notes:
+//   - The existing tables are listed with `Dialect.TableNamesQuery` before any table is described.
+func GetDescriptorsForTablesDialect(ctx context.Context, conn *sql.DB, d Dialect, tableNames []string) (map[string][]TableDescriptor, error) {
+
+	tables, err := GetDbTableNamesDialect(ctx, conn, d)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		existing[t] = true
+	}
+
+	if err := checkTablesExist(tableNames, existing); err != nil {
+		return nil, err
+	}
+
+	return describeTables(ctx, conn, d, tableNames)
+}
+
+// GetDescriptorsForAllTablesFilteredDialect retrieves the table descriptors of the tables
+// selected by a `TableFilter` using the introspection queries of a dialect.
+//
+// Parameters:
+//   - ctx: context.Context - The context controlling cancellation and deadlines of the queries.
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - d: Dialect - The dialect of the database behind `conn`.
+//   - filter: TableFilter - The include and exclude patterns selecting the tables.
+//
+// Returns:
+//   - map[string][]TableDescriptor: A map where the key is the table name (string)
+//     and the value is a slice of `TableDescriptor` containing metadata for the respective table.
+//   - error: An error if any query fails or is cancelled.
+func GetDescriptorsForAllTablesFilteredDialect(ctx context.Context, conn *sql.DB, d Dialect, filter TableFilter) (map[string][]TableDescriptor, error) {
+
+	tables, err := GetDbTableNamesDialect(ctx, conn, d)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]string, 0, len(tables))
+	for _, t := range tables {
+		if filter.Match(t) {
+			selected = append(selected, t)
+		}
+	}
+
+	return describeTables(ctx, conn, d, selected)
+}
+
+// GetDescriptorsForAllTablesParallelDialect retrieves table descriptors for all tables
+// using concurrent queries and the introspection queries of a dialect.
+//
+// Parameters:
+//   - ctx: context.Context - The context controlling cancellation and deadlines of the queries.
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - d: Dialect - The dialect of the database behind `conn`.
+//   - concurrency: int - The maximum number of tables described at the same time. Values
+//     below 1 default to `runtime.NumCPU()`.
+//
+// Returns:
+//   - map[string][]TableDescriptor: A map where the key is the table name (string)
+//     and the value is a slice of `TableDescriptor` containing metadata for the respective table.
+//   - error: The first error returned by a query, or the context error if it is cancelled.
+func GetDescriptorsForAllTablesParallelDialect(ctx context.Context, conn *sql.DB, d Dialect, concurrency int) (map[string][]TableDescriptor, error) {
+
+	if concurrency < 1 {
+		concurrency = runtime.NumCPU()
+	}
+
+	tables, err := GetDbTableNamesDialect(ctx, conn, d)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan string)
+	result := make(map[string][]TableDescriptor, len(tables))
+	var firstErr error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				descriptor, err := GetTableDescriptorDialect(ctx, conn, d, t)
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+					cancel()
+				} else if err == nil {
+					result[t] = descriptor
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, t := range tables {
+		select {
+		case jobs <- t:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// describeTables retrieves the descriptors of the given tables using the introspection
+// query of a dialect.
+func describeTables(ctx context.Context, conn *sql.DB, d Dialect, tables []string) (map[string][]TableDescriptor, error) {
+
+	var err error
+	result := make(map[string][]TableDescriptor)
+
+	for _, t := range tables {
+
+		result[t], err = GetTableDescriptorDialect(ctx, conn, d, t)
+		if err != nil {
+			return nil, err
+		}
+
+	}
+
+	return result, nil
+}
@@ -0,0 +1,231 @@
+package db2go
+
+import (
+	"crypto/tls"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPostgresDataSourceName(t *testing.T) {
+
+	base := ConnectionString{Host: "db.internal", Port: 5432, Timeout: 5, User: "app", Password: "s3cr3t", DatabaseName: "shop"}
+	with := func(change func(c *ConnectionString)) *ConnectionString {
+		c := base
+		change(&c)
+		return &c
+	}
+
+	tests := []struct {
+		name string
+		c    *ConnectionString
+		want string
+	}{
+		{
+			name: "plain",
+			c:    &base,
+			want: `connect_timeout='5' dbname='shop' host='db.internal' password='s3cr3t' port='5432' user='app'`,
+		},
+		{
+			name: "escaped values",
+			c:    with(func(c *ConnectionString) { c.Password = `it's a \ secret` }),
+			want: `connect_timeout='5' dbname='shop' host='db.internal' password='it\'s a \\ secret' port='5432' user='app'`,
+		},
+		{
+			name: "verified TLS",
+			c:    with(func(c *ConnectionString) { c.TLS = "true" }),
+			want: `connect_timeout='5' dbname='shop' host='db.internal' password='s3cr3t' port='5432' sslmode='verify-full' user='app'`,
+		},
+		{
+			name: "preferred TLS",
+			c:    with(func(c *ConnectionString) { c.TLS = "preferred" }),
+			want: `connect_timeout='5' dbname='shop' host='db.internal' password='s3cr3t' port='5432' sslmode='prefer' user='app'`,
+		},
+		{
+			name: "unverified TLS",
+			c:    with(func(c *ConnectionString) { c.TLS = "skip-verify" }),
+			want: `connect_timeout='5' dbname='shop' host='db.internal' password='s3cr3t' port='5432' sslmode='require' user='app'`,
+		},
+		{
+			name: "disabled TLS",
+			c:    with(func(c *ConnectionString) { c.TLS = "false" }),
+			want: `connect_timeout='5' dbname='shop' host='db.internal' password='s3cr3t' port='5432' sslmode='disable' user='app'`,
+		},
+		{
+			name: "native sslmode",
+			c:    with(func(c *ConnectionString) { c.TLS = "verify-ca" }),
+			want: `connect_timeout='5' dbname='shop' host='db.internal' password='s3cr3t' port='5432' sslmode='verify-ca' user='app'`,
+		},
+		{
+			name: "params",
+			c: with(func(c *ConnectionString) {
+				c.TLS = "true"
+				c.Params = map[string]string{"application_name": "db2go", "sslmode": "verify-ca"}
+			}),
+			want: `application_name='db2go' connect_timeout='5' dbname='shop' host='db.internal' password='s3cr3t' port='5432' sslmode='verify-ca' user='app'`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Postgres.DataSourceName(tc.c); got != tc.want {
+				t.Errorf("DataSourceName() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTLSConfigRequiresMySQL(t *testing.T) {
+
+	c := &ConnectionString{Dialect: Postgres, TLSConfig: &tls.Config{ServerName: "db.internal"}}
+	_, err := openConnection(c)
+	if !errors.Is(err, ErrOpenConnection) || !strings.Contains(err.Error(), "postgres") {
+		t.Errorf("openConnection() = %v, want an ErrOpenConnection naming the driver", err)
+	}
+}
+
+func TestPostgresMapType(t *testing.T) {
+
+	tests := []struct {
+		columnType string
+		null       string
+		want       string
+	}{
+		{"text", "NO", "string"},
+		{"character varying", "NO", "string"},
+		{"varchar(255)", "YES", "*string"},
+		{"bpchar", "NO", "string"},
+		{"uuid", "NO", "string"},
+		{"citext", "NO", "string"},
+		{"bigint", "NO", "int64"},
+		{"int8", "YES", "*int64"},
+		{"bigserial", "NO", "int64"},
+		{"integer", "NO", "int32"},
+		{"int4", "NO", "int32"},
+		{"serial", "NO", "int32"},
+		{"smallint", "NO", "int16"},
+		{"int2", "YES", "*int16"},
+		{"numeric(10,2)", "NO", "float64"},
+		{"double precision", "NO", "float64"},
+		{"real", "NO", "float64"},
+		{"timestamp with time zone", "NO", "time.Time"},
+		{"timestamptz", "YES", "*time.Time"},
+		{"date", "NO", "time.Time"},
+		{"time without time zone", "NO", "time.Time"},
+		{"bytea", "NO", "[]byte"},
+		{"bytea", "YES", "[]byte"},
+		{"boolean", "NO", "bool"},
+		{"bool", "YES", "*bool"},
+		{"json", "NO", "json.RawMessage"},
+		{"jsonb", "YES", "*json.RawMessage"},
+		{" TIMESTAMP ", "NO", "time.Time"},
+		{"tsvector", "NO", "interface{}"},
+		{"point", "YES", "interface{}"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.columnType+"/"+tc.null, func(t *testing.T) {
+			got := Postgres.MapType(TableDescriptor{Field: "c", Type: tc.columnType, Null: tc.null})
+			if got != tc.want {
+				t.Errorf("MapType(%q) = %s, want %s", tc.columnType, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPostgresColumnsQuery(t *testing.T) {
+
+	for _, table := range []string{"users", "user's", `"quoted"`} {
+		t.Run(table, func(t *testing.T) {
+			query, args := Postgres.ColumnsQuery(table)
+
+			if !reflect.DeepEqual(args, []interface{}{table}) {
+				t.Errorf("ColumnsQuery() args = %v, want the table name", args)
+			}
+			if strings.Contains(query, table) {
+				t.Errorf("ColumnsQuery() interpolated the table name in %q", query)
+			}
+			for _, want := range []string{"c.table_name = $1", "c.table_schema = current_schema()", "order by c.ordinal_position", "'PRIMARY KEY'"} {
+				if !strings.Contains(query, want) {
+					t.Errorf("ColumnsQuery() = %q, want it to contain %q", query, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSQLiteMapType(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		columnType string
+		null       string
+		want       string
+	}{
+		// INTEGER affinity: the type contains "INT"
+		{"integer", "INTEGER", "NO", "int64"},
+		{"bigint", "BIGINT", "NO", "int64"},
+		{"unsigned big int", "UNSIGNED BIG INT", "YES", "*int64"},
+		{"lower case", "int", "NO", "int64"},
+		{"int before char", "CHARINT", "NO", "int64"},
+		{"int before blob", "BLOBINT", "NO", "int64"},
+		{"int before float", "FLOATING POINT", "NO", "int64"},
+		// TEXT affinity: the type contains "CHAR", "CLOB" or "TEXT"
+		{"varchar", "VARCHAR(20)", "NO", "string"},
+		{"nchar", "NCHAR(55)", "YES", "*string"},
+		{"clob", "CLOB", "NO", "string"},
+		{"text", "TEXT", "NO", "string"},
+		{"text before blob", "TEXTBLOB", "NO", "string"},
+		{"char before real", "CHARREAL", "NO", "string"},
+		// BLOB affinity: the type contains "BLOB" or is empty
+		{"blob", "BLOB", "NO", "[]byte"},
+		{"nullable blob", "BLOB", "YES", "[]byte"},
+		{"empty", "", "YES", "[]byte"},
+		{"blank", "  ", "NO", "[]byte"},
+		{"blob before real", "BLOBREAL", "NO", "[]byte"},
+		// REAL affinity: the type contains "REAL", "FLOA" or "DOUB"
+		{"real", "REAL", "NO", "float64"},
+		{"double", "DOUBLE PRECISION", "YES", "*float64"},
+		{"float", "FLOAT", "NO", "float64"},
+		// NUMERIC affinity: anything else
+		{"numeric", "NUMERIC", "NO", "float64"},
+		{"decimal", "DECIMAL(10,5)", "NO", "float64"},
+		{"boolean", "BOOLEAN", "NO", "bool"},
+		{"date", "DATE", "YES", "*time.Time"},
+		{"datetime", "DATETIME", "NO", "time.Time"},
+		{"timestamp", "TIMESTAMP", "NO", "time.Time"},
+		{"unknown", "STRING", "NO", "interface{}"},
+		{"unknown nullable", "MONEY", "YES", "interface{}"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := SQLite.MapType(TableDescriptor{Field: "c", Type: tc.columnType, Null: tc.null})
+			if got != tc.want {
+				t.Errorf("MapType(%q) = %s, want %s", tc.columnType, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSQLiteColumnsQuery(t *testing.T) {
+
+	for _, table := range []string{"users", "user's", `"quoted"`} {
+		t.Run(table, func(t *testing.T) {
+			query, args := SQLite.ColumnsQuery(table)
+
+			if !reflect.DeepEqual(args, []interface{}{table}) {
+				t.Errorf("ColumnsQuery() args = %v, want the table name", args)
+			}
+			if strings.Contains(query, table) {
+				t.Errorf("ColumnsQuery() interpolated the table name in %q", query)
+			}
+			for _, want := range []string{"pragma_table_info(?)", "order by cid", `when "notnull" = 1 then 'NO'`, "when pk > 0 then 'PRI'"} {
+				if !strings.Contains(query, want) {
+					t.Errorf("ColumnsQuery() = %q, want it to contain %q", query, want)
+				}
+			}
+		})
+	}
+}
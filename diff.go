@@ -0,0 +1,129 @@
+package db2go
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DiffSchemaDumps compares two schema dumps and describes the differences.
+//
+// A schema dump is the JSON encoding of the map returned by `GetDescriptorsForAllTables`,
+// so this function works entirely offline and is meant to document schema changes in
+// code review. The result is a block of Go line comments, ready to be pasted above the
+// regenerated models, with one section per changed table in sorted order:
+//
+//	// table orders: added
+//	// table users:
+//	//   + email varchar(255) NULL
+//	//   - nickname varchar(20) NULL
+//	//   ~ age int NOT NULL -> bigint NOT NULL
+//
+// Parameters:
+//   - oldPath: string - The path of the dump taken before the change.
+//   - newPath: string - The path of the dump taken after the change.
+//
+// Returns:
+//   - string: The human readable diff, every line starting with "// ", or an empty string
+//     if the schemas are equal.
+//   - error: An error if either dump cannot be read or parsed.
+func DiffSchemaDumps(oldPath, newPath string) (string, error) {
+
+	oldSchema, err := readSchemaDump(oldPath)
+	if err != nil {
+		return "", err
+	}
+
+	newSchema, err := readSchemaDump(newPath)
+	if err != nil {
+		return "", err
+	}
+
+	tables := make([]string, 0, len(oldSchema)+len(newSchema))
+	for t := range oldSchema {
+		tables = append(tables, t)
+	}
+	for t := range newSchema {
+		if _, ok := oldSchema[t]; !ok {
+			tables = append(tables, t)
+		}
+	}
+	sort.Strings(tables)
+
+	result := strings.Builder{}
+	for _, t := range tables {
+		oldColumns, inOld := oldSchema[t]
+		newColumns, inNew := newSchema[t]
+
+		switch {
+		case !inOld:
+			result.WriteString(fmt.Sprintf("// table %s: added\n", t))
+		case !inNew:
+			result.WriteString(fmt.Sprintf("// table %s: removed\n", t))
+		default:
+			changes := diffColumns(oldColumns, newColumns)
+			if len(changes) > 0 {
+				result.WriteString(fmt.Sprintf("// table %s:\n", t))
+				for _, c := range changes {
+					result.WriteString("//   " + c + "\n")
+				}
+			}
+		}
+	}
+
+	return result.String(), nil
+}
+
+// readSchemaDump loads a JSON schema dump from path.
+func readSchemaDump(path string) (map[string][]TableDescriptor, error) {
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading schema dump %s: %w", path, err)
+	}
+
+	result := make(map[string][]TableDescriptor)
+	if err := json.Unmarshal(content, &result); err != nil {
+		return nil, fmt.Errorf("failed parsing schema dump %s: %w", path, err)
+	}
+
+	return result, nil
+}
+
+// diffColumns lists the added, removed and changed columns between two versions of a table.
+func diffColumns(oldColumns, newColumns []TableDescriptor) []string {
+
+	describe := func(t TableDescriptor) string {
+		if t.Null == "YES" {
+			return t.Type + " NULL"
+		}
+		return t.Type + " NOT NULL"
+	}
+
+	oldByName := make(map[string]TableDescriptor, len(oldColumns))
+	for _, c := range oldColumns {
+		oldByName[c.Field] = c
+	}
+
+	newByName := make(map[string]bool, len(newColumns))
+	result := make([]string, 0)
+	for _, c := range newColumns {
+		newByName[c.Field] = true
+		old, ok := oldByName[c.Field]
+		if !ok {
+			result = append(result, fmt.Sprintf("+ %s %s", c.Field, describe(c)))
+		} else if describe(old) != describe(c) {
+			result = append(result, fmt.Sprintf("~ %s %s -> %s", c.Field, describe(old), describe(c)))
+		}
+	}
+
+	for _, c := range oldColumns {
+		if !newByName[c.Field] {
+			result = append(result, fmt.Sprintf("- %s %s", c.Field, describe(c)))
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,63 @@
+package db2go
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffSchemaDumps(t *testing.T) {
+
+	got, err := DiffSchemaDumps(filepath.Join("testdata", "schema_old.json"), filepath.Join("testdata", "schema_new.json"))
+	if err != nil {
+		t.Fatalf("DiffSchemaDumps() = %v", err)
+	}
+
+	want := `// table audit_log: removed
+// table orders: added
+// table users:
+//   ~ age int NOT NULL -> bigint NOT NULL
+//   + email varchar(255) NULL
+//   ~ bio text NULL -> text NOT NULL
+//   - nickname varchar(20) NULL
+`
+	if got != want {
+		t.Errorf("DiffSchemaDumps() =\n%s\nwant\n%s", got, want)
+	}
+
+	// The diff is made of Go comments, so it can be pasted in a source file
+	checkSource(t, "package models\n\n"+got+"var _ = 0\n")
+
+	same, err := DiffSchemaDumps(filepath.Join("testdata", "schema_new.json"), filepath.Join("testdata", "schema_new.json"))
+	if err != nil || same != "" {
+		t.Errorf("DiffSchemaDumps() of equal dumps = %q, %v, want no diff", same, err)
+	}
+}
+
+func TestDiffSchemaDumpsErrors(t *testing.T) {
+
+	malformed := filepath.Join(t.TempDir(), "malformed.json")
+	if err := os.WriteFile(malformed, []byte(`{"users": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	valid := filepath.Join("testdata", "schema_old.json")
+
+	tests := []struct {
+		name    string
+		oldPath string
+		newPath string
+		want    string
+	}{
+		{name: "missing old dump", oldPath: filepath.Join(t.TempDir(), "missing.json"), newPath: valid, want: "failed reading schema dump"},
+		{name: "malformed new dump", oldPath: valid, newPath: malformed, want: "failed parsing schema dump " + malformed},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := DiffSchemaDumps(tc.oldPath, tc.newPath); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("DiffSchemaDumps() = %v, want an error containing %q", err, tc.want)
+			}
+		})
+	}
+}
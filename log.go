@@ -0,0 +1,39 @@
+package db2go
+
+import (
+	"log"
+	"os"
+)
+
+// Logger receives the diagnostic messages of the package, such as the errors logged by
+// the functions that panic. `*log.Logger` satisfies it.
+type Logger interface {
+	Println(v ...interface{})
+}
+
+// logger is the Logger used by the package, writing to standard error by default.
+var logger Logger = log.New(os.Stderr, "db2go: ", log.LstdFlags)
+
+// SetLogger replaces the Logger used by the package.
+//
+// Parameters:
+//   - l: Logger - The logger receiving diagnostic messages. A nil logger discards them.
+//
+// Example Usage:
+//
+//	SetLogger(log.New(io.Discard, "", 0))
+//
+// Notes:
+//   - The logger is shared by the whole package, so it should be set once during
+//     initialization, before any other function of the package is called.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = discardLogger{}
+	}
+	logger = l
+}
+
+// discardLogger is a Logger ignoring every message.
+type discardLogger struct{}
+
+func (discardLogger) Println(...interface{}) {}
@@ -0,0 +1,312 @@
+package db2go
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LookupTable holds the rows of a small reference table, read at generation time by
+// `GetLookupTable`, to be inlined in generated code through `GenerateOptions.LookupTables`.
+type LookupTable struct {
+	// Columns lists the names of the columns of each row, in order.
+	Columns []string
+	// Rows holds the values of each row as returned by the driver: nil for NULL, or an
+	// int64, float64, bool, []byte, string or time.Time.
+	Rows [][]interface{}
+}
+
+// GetLookupTable reads every row of a small reference table, such as `countries` or
+// `currencies`, so it can be inlined in generated code.
+//
+// Parameters:
+//   - conn: *sql.DB - A pointer to an open SQL database connection.
+//   - tableName: string - The name of the table to read.
+//   - tt: []TableDescriptor - The column descriptors of the table, as returned by
+//     `GetTableDescriptor`. Every column is read.
+//   - maxRows: int - The maximum number of rows the table may have. It must be positive.
+//
+// Returns:
+//   - LookupTable: The rows of the table, in primary key order, or in column order for
+//     tables without a primary key.
+//   - error: An error if `maxRows` is not positive, if the table has more than `maxRows`
+//     rows, or if the query fails.
+//
+// Notes:
+//   - At most `maxRows + 1` rows are fetched, so pointing this function at a large table
+//     by mistake fails fast instead of reading it whole.
+//   - Identifiers are quoted with MySQL backticks.
+func GetLookupTable(conn *sql.DB, tableName string, tt []TableDescriptor, maxRows int) (LookupTable, error) {
+	return GetLookupTableContext(context.Background(), conn, tableName, tt, maxRows)
+}
+
+// GetLookupTableContext behaves like `GetLookupTable`, aborting when the context is done.
+func GetLookupTableContext(ctx context.Context, conn *sql.DB, tableName string, tt []TableDescriptor, maxRows int) (LookupTable, error) {
+
+	if maxRows < 1 {
+		return LookupTable{}, fmt.Errorf("maxRows of lookup table %s must be positive, got %d", tableName, maxRows)
+	}
+
+	result := LookupTable{Columns: make([]string, 0, len(tt))}
+	quoted := make([]string, 0, len(tt))
+	for _, t := range tt {
+		result.Columns = append(result.Columns, t.Field)
+		quoted = append(quoted, QuoteIdentifier(t.Field))
+	}
+
+	order := make([]string, 0)
+	for _, key := range PrimaryKeys(tt) {
+		order = append(order, QuoteIdentifier(key))
+	}
+	if len(order) == 0 {
+		order = quoted
+	}
+
+	query := fmt.Sprintf("select %s from %s order by %s limit %d",
+		strings.Join(quoted, ", "), QuoteIdentifier(tableName), strings.Join(order, ", "), maxRows+1)
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return LookupTable{}, fmt.Errorf("failed querying lookup table %s: %w", tableName, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		values := make([]interface{}, len(tt))
+		pointers := make([]interface{}, len(tt))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return LookupTable{}, fmt.Errorf("failed scanning lookup table %s: %w", tableName, err)
+		}
+		if len(result.Rows) == maxRows {
+			return LookupTable{}, fmt.Errorf("lookup table %s has more than %d rows", tableName, maxRows)
+		}
+		result.Rows = append(result.Rows, values)
+	}
+
+	if err = rows.Err(); err != nil {
+		return LookupTable{}, fmt.Errorf("failed reading lookup table %s: %w", tableName, err)
+	}
+
+	return result, nil
+}
+
+// lookupVars returns the `<Struct>Rows` slice literal holding the rows of a lookup table
+// and, when the table has a single primary key column, the `<Struct>By<Key>` map
+// indexing it.
+func (o GenerateOptions) lookupVars(tt []TableDescriptor, tableName string, data LookupTable) (string, error) {
+
+	columns := make(map[string]TableDescriptor, len(tt))
+	for _, t := range tt {
+		columns[t.Field] = t
+	}
+
+	name := o.structName(tableName)
+	rowsVar := o.structBaseName(tableName) + "Rows"
+
+	result := strings.Builder{}
+	result.WriteString(fmt.Sprintf("// %s holds the rows of the %s table, read at generation time.\n", rowsVar, tableName))
+	result.WriteString(fmt.Sprintf("var %s = []%s{\n", rowsVar, name))
+
+	keys := PrimaryKeys(tt)
+	keyLiterals := make([]string, 0, len(data.Rows))
+	for _, row := range data.Rows {
+		if len(row) != len(data.Columns) {
+			return "", fmt.Errorf("lookup table %s has a row of %d values for %d columns", tableName, len(row), len(data.Columns))
+		}
+
+		fields := make([]string, 0, len(row))
+		for i, column := range data.Columns {
+			t, ok := columns[column]
+			if !ok {
+				return "", fmt.Errorf("lookup table %s has no column %s", tableName, column)
+			}
+			if row[i] == nil {
+				continue
+			}
+			literal, err := o.lookupLiteral(tableName, t, row[i])
+			if err != nil {
+				return "", err
+			}
+			fields = append(fields, o.fieldName(column)+": "+literal)
+			if len(keys) == 1 && column == keys[0] {
+				keyLiterals = append(keyLiterals, literal)
+			}
+		}
+		result.WriteString("\t{" + strings.Join(fields, ", ") + "},\n")
+	}
+	result.WriteString("}")
+
+	if len(keys) != 1 || len(keyLiterals) != len(data.Rows) {
+		return result.String(), nil
+	}
+	keyType := o.fieldType(tableName, columns[keys[0]])
+	if strings.HasPrefix(keyType, "[]") || o.bytesType(columns[keys[0]]) != "" {
+		return result.String(), nil
+	}
+
+	mapVar := o.structBaseName(tableName) + "By" + o.fieldName(keys[0])
+	result.WriteString(fmt.Sprintf("\n\n// %s indexes %s by the %s primary key.\n", mapVar, rowsVar, keys[0]))
+	result.WriteString(fmt.Sprintf("var %s = map[%s]%s{\n", mapVar, keyType, name))
+	for i, key := range keyLiterals {
+		result.WriteString(fmt.Sprintf("\t%s: %s[%d],\n", key, rowsVar, i))
+	}
+	result.WriteString("}")
+
+	return result.String(), nil
+}
+
+// lookupLiteral returns the Go literal of a non-NULL value of a column, matching the type
+// of its struct field.
+func (o GenerateOptions) lookupLiteral(tableName string, t TableDescriptor, value interface{}) (string, error) {
+
+	goType := o.fieldType(tableName, t)
+	unsupported := fmt.Errorf("column %s of lookup table %s has type %s, which cannot be inlined", t.Field, tableName, goType)
+
+	if elem, ok := strings.CutPrefix(goType, "*"); ok {
+		literal, err := o.lookupLiteral(tableName, TableDescriptor{Field: t.Field, Type: t.Type, Null: "NO"}, value)
+		if err != nil {
+			return "", err
+		}
+		return "&[]" + elem + "{" + literal + "}[0]", nil
+	}
+
+	switch {
+	case o.bytesType(t) != "", goType == "[]byte", goType == "json.RawMessage":
+		return goType + "(" + strconv.Quote(lookupText(value)) + ")", nil
+	case o.WithEnumTypes && isEnum(t):
+		return strconv.Quote(lookupText(value)), nil
+	case o.WithSetTypes && isSet(t):
+		members := make([]string, 0)
+		if text := lookupText(value); text != "" {
+			for _, m := range strings.Split(text, ",") {
+				members = append(members, strconv.Quote(m))
+			}
+		}
+		return goType + "{" + strings.Join(members, ", ") + "}", nil
+	case goType == nullTimeType:
+		literal, err := o.lookupLiteral(tableName, TableDescriptor{Field: t.Field, Type: "datetime", Null: "NO"}, value)
+		if err != nil {
+			return "", err
+		}
+		return nullTimeType + "{Time: " + literal + ", Valid: true}", nil
+	}
+
+	for base, wrapper := range sqlNullTypes {
+		if wrapper != goType || !strings.HasPrefix(wrapper, "sql.") {
+			continue
+		}
+		literal, err := scalarLiteral(base, value)
+		if err != nil {
+			return "", fmt.Errorf("column %s of lookup table %s: %w", t.Field, tableName, err)
+		}
+		field := strings.TrimPrefix(wrapper, "sql.Null")
+		if strings.HasPrefix(wrapper, "sql.Null[") {
+			field = "V"
+		}
+		return goType + "{" + field + ": " + literal + ", Valid: true}", nil
+	}
+
+	literal, err := scalarLiteral(goType, value)
+	if errors.Is(err, errNotScalar) {
+		return "", unsupported
+	}
+	if err != nil {
+		return "", fmt.Errorf("column %s of lookup table %s: %w", t.Field, tableName, err)
+	}
+
+	return literal, nil
+}
+
+// errNotScalar is returned by scalarLiteral for the Go types it cannot render.
+var errNotScalar = errors.New("not a scalar type")
+
+// scalarLiteral returns the Go literal of a value for a predeclared Go type or time.Time.
+func scalarLiteral(goType string, value interface{}) (string, error) {
+
+	text := lookupText(value)
+
+	switch goType {
+	case "string":
+		return strconv.Quote(text), nil
+	case "bool":
+		switch strings.ToLower(text) {
+		case "1", "true":
+			return "true", nil
+		case "0", "false":
+			return "false", nil
+		}
+		return "", fmt.Errorf("invalid bool value %q", text)
+	case "int", "int8", "int16", "int32", "int64":
+		n, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s value %q", goType, text)
+		}
+		return strconv.FormatInt(n, 10), nil
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		n, err := strconv.ParseUint(text, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s value %q", goType, text)
+		}
+		return strconv.FormatUint(n, 10), nil
+	case "float32", "float64":
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", fmt.Errorf("invalid %s value %q", goType, text)
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64), nil
+	case "time.Time":
+		t, err := lookupTime(value)
+		if err != nil {
+			return "", err
+		}
+		t = t.UTC()
+		return fmt.Sprintf("time.Date(%d, time.%s, %d, %d, %d, %d, %d, time.UTC)",
+			t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond()), nil
+	}
+
+	return "", errNotScalar
+}
+
+// lookupText returns the text of a value returned by the driver.
+func lookupText(value interface{}) string {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.999999999")
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// lookupTime returns the time of a value returned by the driver, parsing the text of
+// DATE and DATETIME columns when the driver does not parse them itself.
+func lookupTime(value interface{}) (time.Time, error) {
+
+	if t, ok := value.(time.Time); ok {
+		return t, nil
+	}
+
+	text := lookupText(value)
+	for _, layout := range []string{"2006-01-02 15:04:05.999999999", "2006-01-02", time.RFC3339Nano} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time value %q", text)
+}
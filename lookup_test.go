@@ -0,0 +1,136 @@
+package db2go
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countries describes a small lookup table with nullable and time columns.
+var countries = []TableDescriptor{
+	{Field: "code", Type: "char(2)", Null: "NO", Key: "PRI"},
+	{Field: "name", Type: "varchar(64)", Null: "NO"},
+	{Field: "population", Type: "bigint unsigned", Null: "YES"},
+	{Field: "eu", Type: "tinyint(1)", Null: "NO"},
+	{Field: "continent", Type: "enum('Europe','America')", Null: "NO"},
+	{Field: "joined_at", Type: "datetime", Null: "YES"},
+}
+
+func TestGetLookupTable(t *testing.T) {
+
+	joined := time.Date(1986, time.January, 1, 0, 0, 0, 0, time.UTC)
+	rows := [][]driver.Value{
+		{[]byte("AR"), []byte("Argentina"), int64(46000000), int64(0), []byte("America"), nil},
+		{[]byte("ES"), []byte("España \"ES\""), nil, int64(1), []byte("Europe"), joined},
+	}
+	db := &stubDB{handler: func(query string, args []driver.NamedValue) (*stubRows, error) {
+		want := "select `code`, `name`, `population`, `eu`, `continent`, `joined_at` from `countries` order by `code` limit "
+		if !strings.HasPrefix(query, want) {
+			return nil, fmt.Errorf("unexpected query %q", query)
+		}
+		return &stubRows{columns: []string{"code", "name", "population", "eu", "continent", "joined_at"}, rows: rows}, nil
+	}}
+	conn := db.open(t)
+
+	data, err := GetLookupTable(conn, "countries", countries, 2)
+	if err != nil {
+		t.Fatalf("GetLookupTable() = %v", err)
+	}
+	if q := db.executed()[0]; !strings.HasSuffix(q, " limit 3") {
+		t.Errorf("GetLookupTable() ran %q, want one row over the limit fetched", q)
+	}
+	if len(data.Rows) != 2 || !reflect.DeepEqual(data.Columns, []string{"code", "name", "population", "eu", "continent", "joined_at"}) {
+		t.Fatalf("GetLookupTable() = %+v, want 2 rows of every column", data)
+	}
+
+	if _, err := GetLookupTable(conn, "countries", countries, 1); err == nil || err.Error() != "lookup table countries has more than 1 rows" {
+		t.Errorf("GetLookupTable() over the limit = %v, want a limit error", err)
+	}
+	if _, err := GetLookupTable(conn, "countries", countries, 0); err == nil || !strings.Contains(err.Error(), "must be positive") {
+		t.Errorf("GetLookupTable() without a limit = %v, want a limit error", err)
+	}
+
+	denied := errors.New("Error 1142: SELECT command denied")
+	failing := &stubDB{handler: func(string, []driver.NamedValue) (*stubRows, error) { return nil, denied }}
+	if _, err := GetLookupTable(failing.open(t), "countries", countries, 10); !errors.Is(err, denied) {
+		t.Errorf("GetLookupTable() = %v, want it to wrap %v", err, denied)
+	}
+
+	opts := GenerateOptions{
+		TinyIntAsBool: true,
+		WithEnumTypes: true,
+		LookupTables:  map[string]LookupTable{"countries": data},
+	}
+	src := generateFile(t, map[string][]TableDescriptor{"countries": countries}, opts)
+	checkSource(t, src)
+
+	for _, want := range []string{
+		"var CountriesRows = []CountriesData{\n",
+		"\t{Code: \"AR\", Name: \"Argentina\", Population: &[]uint64{46000000}[0], Eu: false, Continent: \"America\"},\n",
+		"JoinedAt: &[]time.Time{time.Date(1986, time.January, 1, 0, 0, 0, 0, time.UTC)}[0]},\n",
+		"var CountriesByCode = map[string]CountriesData{\n\t\"AR\": CountriesRows[0],\n\t\"ES\": CountriesRows[1],\n}",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated code has no %q:\n%s", want, src)
+		}
+	}
+
+	out := runGenerated(t, src, `
+	es := CountriesByCode["ES"]
+	fmt.Println(len(CountriesRows), es.Name, es.Population == nil, es.Eu, es.Continent == CountriesContinentEurope, es.JoinedAt.Year())`)
+	if want := "2 España \"ES\" true true true 1986\n"; out != want {
+		t.Errorf("generated lookup table printed %q, want %q", out, want)
+	}
+}
+
+func TestLookupLiterals(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		column  TableDescriptor
+		opts    GenerateOptions
+		value   interface{}
+		want    string
+		wantErr string
+	}{
+		{name: "string", column: TableDescriptor{Type: "varchar(10)", Null: "NO"}, value: []byte("a\"b"), want: `"a\"b"`},
+		{name: "int", column: TableDescriptor{Type: "int", Null: "NO"}, value: int64(-3), want: "-3"},
+		{name: "int text", column: TableDescriptor{Type: "smallint", Null: "NO"}, value: []byte("42"), want: "42"},
+		{name: "float", column: TableDescriptor{Type: "double", Null: "NO"}, value: []byte("0.25"), want: "0.25"},
+		{name: "bytes", column: TableDescriptor{Type: "varbinary(4)", Null: "YES"}, value: []byte{0, 0xff}, want: `[]byte("\x00\xff")`},
+		{name: "json", column: TableDescriptor{Type: "json", Null: "NO"}, value: []byte(`{"a":1}`), want: "json.RawMessage(\"{\\\"a\\\":1}\")"},
+		{name: "date text", column: TableDescriptor{Type: "date", Null: "NO"}, value: []byte("2024-02-29"), want: "time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)"},
+		{name: "null wrapper", column: TableDescriptor{Type: "varchar(10)", Null: "YES"}, opts: GenerateOptions{NullStrategy: NullSQL}, value: "x", want: `sql.NullString{String: "x", Valid: true}`},
+		{name: "generic null wrapper", column: TableDescriptor{Type: "tinyint", Null: "YES"}, opts: GenerateOptions{NullStrategy: NullSQL}, value: int64(1), want: "sql.Null[int8]{V: 1, Valid: true}"},
+		{name: "null time", column: TableDescriptor{Type: "datetime", Null: "YES"}, opts: GenerateOptions{WithNullTime: true}, value: time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC), want: "NullTime{Time: time.Date(2024, time.May, 6, 7, 8, 9, 10, time.UTC), Valid: true}"},
+		{name: "set", column: TableDescriptor{Field: "tags", Type: "set('a','b')", Null: "NO"}, opts: GenerateOptions{WithSetTypes: true}, value: []byte("a,b"), want: `ItemsTags{"a", "b"}`},
+		{name: "empty set", column: TableDescriptor{Field: "tags", Type: "set('a','b')", Null: "NO"}, opts: GenerateOptions{WithSetTypes: true}, value: []byte(""), want: "ItemsTags{}"},
+		{name: "invalid int", column: TableDescriptor{Field: "qty", Type: "int", Null: "NO"}, value: []byte("x"), wantErr: `column qty of lookup table items: invalid int32 value "x"`},
+		{name: "decimal type", column: TableDescriptor{Field: "price", Type: "decimal(10,2)", Null: "NO"}, opts: GenerateOptions{DecimalType: DecimalShopspring}, value: []byte("1.50"), wantErr: "column price of lookup table items has type decimal.Decimal, which cannot be inlined"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.column.Field == "" {
+				tc.column.Field = "value"
+			}
+			got, err := tc.opts.lookupLiteral("items", tc.column, tc.value)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Errorf("lookupLiteral() = %v, want %s", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("lookupLiteral() = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("lookupLiteral() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
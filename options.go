@@ -0,0 +1,1062 @@
+package db2go
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// GenerateOptions configures the code produced by the struct generation functions.
+//
+// The zero value is valid and reproduces the output of `CreateStruct` with JSON tags
+// disabled: MySQL type mapping, schema field order, pointers for nullable columns and
+// a "Data" struct suffix.
+type GenerateOptions struct {
+	// WithJSON adds a `json` tag to every struct field.
+	WithJSON bool
+	// WithDBTags adds a `db` tag holding the column name to every struct field, as used by
+	// sqlx. Tags are always written in the db, gorm, json, default, redact order.
+	WithDBTags bool
+	// WithDefaultTags adds a `default` tag holding the column default value as reported by
+	// the database, e.g. `default:"0"` or `default:"pending"`. Columns without a default
+	// get no tag, and neither do columns whose default is an expression evaluated by the
+	// database, like `CURRENT_TIMESTAMP`, `NOW()` or `(UUID())`, which are not values.
+	WithDefaultTags bool
+	// WithGORMTags adds a `gorm` tag to every struct field, holding the column name and the
+	// primaryKey, autoIncrement, not null and default directives derived from the column
+	// descriptor, e.g. `gorm:"column:id;primaryKey;autoIncrement"`.
+	WithGORMTags bool
+	// WithTableNameMethod adds a `TableName() string` method to every struct, returning
+	// the original table name whatever the struct name is, as expected by GORM and
+	// most query builders. The field of a `table_name` column is renamed `TableName_`.
+	WithTableNameMethod bool
+	// WithConstructors adds a `New<Struct>` function after every struct, taking the NOT
+	// NULL columns that are not auto increment as parameters, in field order, and
+	// returning the struct populated with them.
+	WithConstructors bool
+	// WithStringer adds a `String() string` method to every struct, as generated by
+	// `CreateStringMethod`. Nil pointers are printed as `<nil>` and `[]byte` fields in
+	// truncated hex. Columns selected by Redaction are masked. The field of a `string`
+	// column is renamed `String_`.
+	WithStringer bool
+	// Redaction selects the sensitive columns, e.g. passwords or tokens. Their fields get a
+	// `redact:"true"` tag and are masked by the String method of WithStringer. A nil
+	// Redaction marks nothing.
+	Redaction *RedactionPolicy
+	// WithColumnConstants adds a constant holding the name of each column after every
+	// struct, named after the struct base name and the column, e.g. `UsersColEmail`.
+	WithColumnConstants bool
+	// WithColumnsSlice adds a variable listing the column names of the table in schema
+	// order after every struct, named after the struct base name, e.g. `UsersColumns`.
+	WithColumnsSlice bool
+	// WithQueryHelpers adds constants holding the SELECT, INSERT, UPDATE and DELETE
+	// statements of the table after every struct, e.g. `UsersInsertQuery`. UPDATE and
+	// DELETE match every primary key column and are omitted for tables without one, and INSERT
+	// skips auto increment columns. Statements use MySQL quoting and `?` placeholders.
+	// Views only get the SELECT statement.
+	WithQueryHelpers bool
+	// WithFieldComments writes the comment of each column above its struct field. Line
+	// breaks and repeated spaces of the comment are collapsed into single spaces.
+	WithFieldComments bool
+	// WithProtoNumbers annotates every struct field with a `// proto: N` comment holding a
+	// stable protobuf field number, the ordinal position of the column. Columns added with
+	// `ALTER TABLE ... ADD COLUMN` get the next free position, so existing numbers do not
+	// change on regeneration; columns added with FIRST or AFTER, or dropped, shift the
+	// numbers of the columns following them. Without `OrdinalPosition`, as reported by
+	// `GetSchemaDescriptors`, the position of the column in the descriptors is used.
+	WithProtoNumbers bool
+	// StructTemplate replaces DefaultStructTemplate to render the struct declarations. It
+	// is a `text/template` executed with a `StructView`. Methods and declarations enabled
+	// by other options are still written after the rendered struct.
+	StructTemplate string
+	// JSONNaming selects how the `json` tag name is derived from the column name.
+	// Defaults to JSONNamingOriginal.
+	JSONNaming JSONNaming
+	// JSONOmitEmpty appends `,omitempty` to the `json` tag of nullable columns.
+	JSONOmitEmpty bool
+	// StructSuffix is appended to the camelized table name to form the struct name, e.g.
+	// "Model" or "Row". An empty value keeps the default "Data" suffix.
+	StructSuffix string
+	// OmitStructSuffix uses the camelized table name alone as the struct name, e.g.
+	// `type Users struct`. It takes precedence over StructSuffix.
+	OmitStructSuffix bool
+	// TrimTablePrefix lists prefixes, e.g. "tbl_", removed from table names before they
+	// are camelized into struct names. Only the first matching prefix is removed, and the
+	// original table name is still used wherever the table itself is referenced.
+	// Generation fails if two tables end up with the same struct name.
+	TrimTablePrefix []string
+	// Singularize converts the table name to its singular form before it is camelized
+	// into a struct name, e.g. `users` becomes `User`. See `Singularize`. Generation fails
+	// if two tables end up with the same struct name, e.g. `user` and `users`.
+	Singularize bool
+	// AppendToFile appends generated code to an existing file instead of overwriting it.
+	// Only the named types and structs are appended to a file that already has content;
+	// its header, package clause and imports are kept, and must cover the new code. Named
+	// types the file already declares are not declared again, and generation fails if it
+	// already declares the struct of a table.
+	AppendToFile bool
+	// HeaderComment is written above the package clause of generated files, e.g. a license
+	// block. Lines not starting with "//" are turned into comments. An empty value writes
+	// DefaultHeaderComment, the marker recognized by Go tooling as generated code.
+	HeaderComment string
+	// OmitHeaderComment writes generated files without any header comment. It takes
+	// precedence over HeaderComment.
+	OmitHeaderComment bool
+	// PackageName is the package clause of generated files, for the functions that
+	// write complete files and do not take it as a parameter.
+	PackageName string
+	// Initialisms lists the words rendered fully upper case in field and struct names.
+	// A nil list uses CommonInitialisms; extend it with
+	// `append(db2go.CommonInitialisms, "SKU")`.
+	Initialisms []string
+	// KeywordFieldSuffix is appended to the field name of columns named after a Go keyword,
+	// e.g. "Kind" names the field of a `type` column TypeKind, and "_" names it Type_.
+	// Tags keep the original column name. An empty value leaves these fields named like
+	// any other column, which is valid since field names are capitalized.
+	KeywordFieldSuffix string
+	// FieldOrder selects the order of the struct fields. Defaults to FieldOrderSchema.
+	FieldOrder FieldOrder
+	// Dialect provides the SQL-to-Go type mapping. A nil Dialect selects MySQL.
+	Dialect Dialect
+	// NullStrategy selects how nullable columns are represented. Defaults to NullPointer.
+	NullStrategy NullStrategy
+	// WithNullTime renders nullable `time.Time` columns as a generated `NullTime` type,
+	// whatever the NullStrategy, so they marshal to JSON as an RFC 3339 string or null.
+	// The declaration is emitted once by the functions writing complete files; see
+	// `CreateNullTimeType` to generate it on its own.
+	WithNullTime bool
+	// TinyIntAsBool maps `TINYINT(1)` columns, the MySQL convention for booleans, to bool.
+	// Other TINYINT widths keep mapping to int8.
+	TinyIntAsBool bool
+	// DecimalType selects the Go type of DECIMAL and NUMERIC columns. Defaults to
+	// DecimalFloat64; DecimalString or DecimalShopspring are recommended for monetary
+	// data, since float64 cannot represent most decimal values exactly.
+	DecimalType DecimalType
+	// PreserveFloatWidth maps single precision FLOAT columns to float32 instead of float64.
+	// With NullSQL, nullable FLOAT columns use the generic `sql.Null[float32]`, which
+	// requires Go 1.22.
+	PreserveFloatWidth bool
+	// YearAsTime maps YEAR columns to time.Time instead of int16.
+	YearAsTime bool
+	// TimeTypeMapping overrides the Go type of the DATE, TIME, DATETIME, TIMESTAMP and YEAR
+	// columns. Types left empty keep the default mapping.
+	TimeTypeMapping TimeTypeMapping
+	// CustomTypeMap maps base column types, upper cased and without length or `UNSIGNED`
+	// (e.g. "GEOMETRY"), to Go types (e.g. "geo.Point"). It is consulted before any other
+	// mapping, so it can override known types as well as map unknown ones. Use
+	// `MergeTypeMap` to add a mapping read by `LoadTypeMap`.
+	CustomTypeMap map[string]string
+	// CustomTypeImports maps the base column types of CustomTypeMap to the import path
+	// required by their Go type, e.g. "GEOMETRY" to "example.com/geo".
+	CustomTypeImports map[string]string
+	// JSONType selects the Go type of JSON columns. Defaults to JSONRawMessage.
+	JSONType JSONType
+	// WithSetTypes renders SET columns as a named `[]string` type, e.g. `UsersTags` for the
+	// `tags` column of the `users` table, or `UserTags` with Singularize, implementing
+	// `sql.Scanner` and `driver.Valuer`.
+	// The declarations are emitted by the functions writing complete files; see
+	// `CreateSetType` to generate one on its own.
+	WithSetTypes bool
+	// WithEnumTypes renders ENUM columns as a named string type, e.g. `UsersStatus` for the
+	// `status` column of the `users` table, or `UserStatus` with Singularize, declared
+	// with a constant per allowed value.
+	// The declarations are emitted by the functions writing complete files; see
+	// `CreateEnumType` to generate one on its own.
+	WithEnumTypes bool
+	// BytesTypes renders the binary columns selected by each entry as a named `[]byte`
+	// type, e.g. `HashBytes` for the `sha256` and `parent_hash` columns, with `Hex` and
+	// `Parse<Type>Hex` helpers. Only columns whose Go type would otherwise be `[]byte`,
+	// once CustomTypeMap and every other option is applied, are affected, and the first
+	// matching entry wins. A nil slice keeps every binary column a plain `[]byte`.
+	// The declarations are emitted once by the functions writing complete files; see
+	// `CreateBytesType` to generate one on its own.
+	BytesTypes []BytesType
+	// LookupTables inlines the rows of small reference tables, e.g. `countries`, read at
+	// generation time by `GetLookupTable` and keyed by table name. Each listed table gets
+	// a `<Struct>Rows` slice literal after its struct, e.g. `CountriesRows`, and, when it
+	// has a single primary key column, a map indexing it, e.g. `CountriesByCode`. Tables
+	// not listed only get their struct. Generation fails if a value cannot be written as
+	// a literal of its field type, e.g. for DECIMAL columns with DecimalShopspring.
+	LookupTables map[string]LookupTable
+}
+
+// BytesType selects binary columns by name and names the `[]byte` type they are rendered as.
+type BytesType struct {
+	// Matcher selects the columns by name, e.g. `regexp.MustCompile("(^|_)hash$")`.
+	Matcher *regexp.Regexp
+	// Name is the name of the generated type, e.g. "HashBytes".
+	Name string
+}
+
+// JSONType selects the Go representation of JSON columns.
+type JSONType int
+
+const (
+	// JSONRawMessage renders JSON columns as encoding/json.RawMessage, so the document is
+	// embedded unchanged when the struct is marshalled.
+	JSONRawMessage JSONType = iota
+	// JSONBytes renders JSON columns as []byte. As for BLOB columns, a nil slice stands
+	// for NULL, so nullable columns are not pointers.
+	JSONBytes
+	// JSONString renders JSON columns as string.
+	JSONString
+)
+
+// GoType names a Go type used by generated code, together with the package it requires.
+type GoType struct {
+	// Name is the type as written in generated code, e.g. "civil.Date" or "string".
+	Name string
+	// ImportPath is the import path of the package of the type, e.g.
+	// "cloud.google.com/go/civil". It is empty for predeclared types.
+	ImportPath string
+}
+
+// TimeTypeMapping selects the Go type of each date and time column type. The zero value
+// keeps the default mapping of every type.
+type TimeTypeMapping struct {
+	Date      GoType
+	Time      GoType
+	DateTime  GoType
+	Timestamp GoType
+	Year      GoType
+}
+
+// lookup returns the Go type configured for a base column type, if any.
+func (m TimeTypeMapping) lookup(cleanType string) (GoType, bool) {
+
+	result := GoType{}
+	switch cleanType {
+	case "DATE":
+		result = m.Date
+	case "TIME":
+		result = m.Time
+	case "DATETIME":
+		result = m.DateTime
+	case "TIMESTAMP":
+		result = m.Timestamp
+	case "YEAR":
+		result = m.Year
+	}
+
+	return result, result.Name != ""
+}
+
+// DefaultHeaderComment marks generated files as such, matching the
+// `^// Code generated .* DO NOT EDIT\.$` convention of Go tooling.
+const DefaultHeaderComment = "// Code generated by db2go; DO NOT EDIT."
+
+// headerComment returns the comment written above the package clause, followed by an
+// empty line so it is not taken as the package documentation.
+func (o GenerateOptions) headerComment() string {
+
+	if o.OmitHeaderComment {
+		return ""
+	}
+
+	header := o.HeaderComment
+	if header == "" {
+		header = DefaultHeaderComment
+	}
+
+	result := strings.Builder{}
+	for _, line := range strings.Split(strings.TrimRight(header, "\n"), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "//") {
+			line = strings.TrimRight("// "+line, " ")
+		}
+		result.WriteString(line + "\n")
+	}
+	result.WriteString("\n")
+
+	return result.String()
+}
+
+// DecimalType selects the Go representation of DECIMAL and NUMERIC columns.
+type DecimalType int
+
+const (
+	// DecimalFloat64 renders decimals as float64, which may lose precision.
+	DecimalFloat64 DecimalType = iota
+	// DecimalString renders decimals as string, keeping the exact database value.
+	DecimalString
+	// DecimalShopspring renders decimals as github.com/shopspring/decimal.Decimal.
+	DecimalShopspring
+)
+
+// decimalGoTypes maps each DecimalType to its Go type.
+var decimalGoTypes = map[DecimalType]string{
+	DecimalFloat64:    "float64",
+	DecimalString:     "string",
+	DecimalShopspring: "decimal.Decimal",
+}
+
+// JSONNaming selects how `json` tag names are derived from column names.
+type JSONNaming int
+
+const (
+	// JSONNamingOriginal uses the column name unchanged, e.g. `user_id`.
+	JSONNamingOriginal JSONNaming = iota
+	// JSONNamingCamelCase camelizes the column name, e.g. `userId`.
+	JSONNamingCamelCase
+	// JSONNamingSnakeCase converts the column name to snake_case, e.g. `UserID` to `user_id`.
+	JSONNamingSnakeCase
+)
+
+// structTags returns the tags of the struct field of a column, e.g.
+// `db:"created_at" json:"created_at"`, or an empty string if no tag is enabled.
+func (o GenerateOptions) structTags(t TableDescriptor) string {
+
+	tags := make([]string, 0)
+	if o.WithDBTags {
+		tags = append(tags, fmt.Sprintf("db:\"%s\"", t.Field))
+	}
+	if o.WithGORMTags {
+		tags = append(tags, fmt.Sprintf("gorm:\"%s\"", gormTag(t)))
+	}
+	if o.WithJSON {
+		tags = append(tags, fmt.Sprintf("json:\"%s\"", o.jsonTag(t)))
+	}
+	if o.WithDefaultTags && t.Default != nil && !isExpressionDefault(t) {
+		tags = append(tags, fmt.Sprintf("default:%q", *t.Default))
+	}
+	if o.Redaction.IsSensitive(t.Field) {
+		tags = append(tags, `redact:"true"`)
+	}
+
+	return strings.Join(tags, " ")
+}
+
+// expressionDefault matches the default values MySQL and MariaDB report for expressions:
+// the temporal keywords, optionally with a precision, function calls and parenthesized
+// expressions.
+var expressionDefault = regexp.MustCompile(`(?i)^(?:(?:CURRENT_TIMESTAMP|CURRENT_DATE|CURRENT_TIME|LOCALTIME|LOCALTIMESTAMP|NOW|UTC_TIMESTAMP|UTC_DATE|UTC_TIME)(?:\(\d*\))?|[a-z_][a-z0-9_]*\(.*\)|\(.*\))$`)
+
+// isExpressionDefault reports whether the default of a column is an expression evaluated
+// by the database rather than a literal value. MySQL 8 flags expression defaults with
+// DEFAULT_GENERATED in Extra; older servers and MariaDB are recognized from the value.
+func isExpressionDefault(t TableDescriptor) bool {
+	if t.Default == nil {
+		return false
+	}
+	if strings.Contains(strings.ToUpper(t.Extra), "DEFAULT_GENERATED") {
+		return true
+	}
+	return expressionDefault.MatchString(strings.TrimSpace(*t.Default))
+}
+
+// gormTag returns the value of the `gorm` tag of a column.
+func gormTag(t TableDescriptor) string {
+
+	directives := []string{"column:" + t.Field}
+	if t.Key == "PRI" {
+		directives = append(directives, "primaryKey")
+	}
+	if IsAutoIncrement(t) {
+		directives = append(directives, "autoIncrement")
+	}
+	if t.Null == "NO" && t.Key != "PRI" {
+		directives = append(directives, "not null")
+	}
+	if t.Default != nil {
+		value := *t.Default
+		if value == "" {
+			value = "''"
+		}
+		directives = append(directives, "default:"+strings.ReplaceAll(value, "\"", "\\\""))
+	}
+
+	return strings.Join(directives, ";")
+}
+
+// jsonTag returns the value of the `json` tag of a column.
+func (o GenerateOptions) jsonTag(t TableDescriptor) string {
+
+	result := t.Field
+	switch o.JSONNaming {
+	case JSONNamingCamelCase:
+		result = CamelizeWithInitialisms(t.Field, false, nil)
+	case JSONNamingSnakeCase:
+		result = ToSnakeCaseWithInitialisms(t.Field, o.initialisms())
+	}
+
+	if o.JSONOmitEmpty && isNullable(t) {
+		result += ",omitempty"
+	}
+
+	return result
+}
+
+// NullStrategy selects the Go representation of nullable columns.
+type NullStrategy int
+
+const (
+	// NullPointer renders nullable columns as pointers, e.g. `*string`.
+	NullPointer NullStrategy = iota
+	// NullSQL renders nullable columns with the `database/sql` wrappers, e.g. `sql.NullString`.
+	// Integer columns use the wrapper of their width, `sql.NullInt64`, `sql.NullInt32`,
+	// `sql.NullInt16` or `sql.NullByte`; the widths without one, `int8` and the unsigned
+	// integers other than `uint8`, use the generic `sql.Null[T]`, which requires Go 1.22.
+	// Types without a wrapper keep the pointer representation.
+	NullSQL
+)
+
+// sqlNullTypes maps the base Go types to their `database/sql` null wrapper.
+var sqlNullTypes = map[string]string{
+	"string":    "sql.NullString",
+	"int64":     "sql.NullInt64",
+	"int32":     "sql.NullInt32",
+	"int16":     "sql.NullInt16",
+	"int8":      "sql.Null[int8]",
+	"uint64":    "sql.Null[uint64]",
+	"uint32":    "sql.Null[uint32]",
+	"uint16":    "sql.Null[uint16]",
+	"uint8":     "sql.NullByte",
+	"float64":   "sql.NullFloat64",
+	"float32":   "sql.Null[float32]",
+	"bool":      "sql.NullBool",
+	"time.Time": "sql.NullTime",
+	// Not part of database/sql, but the shopspring package provides the same wrapper
+	"decimal.Decimal": "decimal.NullDecimal",
+}
+
+// goType returns the Go type of a column once every option is applied.
+func (o GenerateOptions) goType(t TableDescriptor) string {
+
+	if custom, ok := o.CustomTypeMap[baseType(t.Type)]; ok {
+		return o.nullable(t, custom)
+	}
+
+	if o.TinyIntAsBool && isTinyIntOne(t.Type) {
+		t.Type = "BOOL"
+	}
+
+	result := o.dialect().MapType(t)
+
+	if o.YearAsTime && baseType(t.Type) == "YEAR" {
+		result = "time.Time"
+		if isNullable(t) {
+			result = "*" + result
+		}
+	}
+
+	if custom, ok := o.TimeTypeMapping.lookup(baseType(t.Type)); ok {
+		result = custom.Name
+		if isNullable(t) {
+			result = "*" + result
+		}
+	}
+
+	if o.PreserveFloatWidth {
+		if cleanType := baseType(t.Type); cleanType == "FLOAT" || cleanType == "FLOAT4" {
+			result = "float32"
+			if isNullable(t) {
+				result = "*" + result
+			}
+		}
+	}
+
+	if o.DecimalType != DecimalFloat64 {
+		if cleanType := baseType(t.Type); cleanType == "DECIMAL" || cleanType == "NUMERIC" {
+			result = decimalGoTypes[o.DecimalType]
+			if isNullable(t) {
+				result = "*" + result
+			}
+		}
+	}
+
+	if o.JSONType != JSONRawMessage {
+		if cleanType := baseType(t.Type); cleanType == "JSON" || cleanType == "JSONB" {
+			switch {
+			case o.JSONType == JSONBytes:
+				result = "[]byte"
+			case isNullable(t):
+				result = "*string"
+			default:
+				result = "string"
+			}
+		}
+	}
+
+	if o.WithNullTime && result == "*time.Time" {
+		return nullTimeType
+	}
+
+	if o.NullStrategy == NullSQL && strings.HasPrefix(result, "*") {
+		if wrapper, ok := sqlNullTypes[result[1:]]; ok {
+			result = wrapper
+		}
+	}
+
+	return result
+}
+
+// nullable returns the representation of goType for a column following the NullStrategy.
+// Slices are never made pointers, since a nil slice already represents NULL.
+func (o GenerateOptions) nullable(t TableDescriptor, goType string) string {
+
+	if !isNullable(t) || strings.HasPrefix(goType, "[]") {
+		return goType
+	}
+
+	if o.WithNullTime && goType == "time.Time" {
+		return nullTimeType
+	}
+
+	if o.NullStrategy == NullSQL {
+		if wrapper, ok := sqlNullTypes[goType]; ok {
+			return wrapper
+		}
+	}
+
+	return "*" + goType
+}
+
+// Validate checks that the options can be used together before any code is generated.
+//
+// Options are independent fields, so nothing prevents setting two of them that contradict
+// each other, or mapping a column to a type whose package cannot be imported. This method
+// reports such combinations, naming the conflicting fields, so they fail fast instead of
+// producing surprising or uncompilable output. `CreateAllTablesStructToWriter` calls it
+// before writing anything.
+//
+// Returns:
+//   - error: An error listing every problem found, or nil if the options are consistent.
+//
+// Checked Combinations:
+//   - A `CustomTypeMap` or `TimeTypeMapping` type qualified by a package, e.g. "geo.Point",
+//     must have an import path, through `CustomTypeImports` or `GoType.ImportPath`, unless
+//     the package is one the generator already knows, like "time" or "sql".
+//   - `StructSuffix` cannot be set along with `OmitStructSuffix`.
+//   - `HeaderComment` cannot be set along with `OmitHeaderComment`.
+//   - `YearAsTime` cannot be set along with `TimeTypeMapping.Year`.
+//   - Every `BytesTypes` entry needs a Matcher and a Name that is a valid identifier.
+func (o GenerateOptions) Validate() error {
+
+	problems := make([]string, 0)
+
+	columnTypes := make([]string, 0, len(o.CustomTypeMap))
+	for k := range o.CustomTypeMap {
+		columnTypes = append(columnTypes, k)
+	}
+	sort.Strings(columnTypes)
+	for _, k := range columnTypes {
+		if _, ok := o.CustomTypeImports[k]; ok {
+			continue
+		}
+		if qualifier := typeQualifier(o.CustomTypeMap[k]); qualifier != "" {
+			if _, ok := knownImports[qualifier]; !ok {
+				problems = append(problems, fmt.Sprintf("CustomTypeMap maps %s to %s but CustomTypeImports has no import path for it", k, o.CustomTypeMap[k]))
+			}
+		}
+	}
+
+	timeTypes := []struct {
+		field  string
+		goType GoType
+	}{
+		{"Date", o.TimeTypeMapping.Date},
+		{"Time", o.TimeTypeMapping.Time},
+		{"DateTime", o.TimeTypeMapping.DateTime},
+		{"Timestamp", o.TimeTypeMapping.Timestamp},
+		{"Year", o.TimeTypeMapping.Year},
+	}
+	for _, t := range timeTypes {
+		if qualifier := typeQualifier(t.goType.Name); qualifier != "" && t.goType.ImportPath == "" {
+			if _, ok := knownImports[qualifier]; !ok {
+				problems = append(problems, fmt.Sprintf("TimeTypeMapping.%s uses %s but has no ImportPath", t.field, t.goType.Name))
+			}
+		}
+	}
+
+	if o.StructSuffix != "" && o.OmitStructSuffix {
+		problems = append(problems, "StructSuffix is set but OmitStructSuffix discards it")
+	}
+	if o.HeaderComment != "" && o.OmitHeaderComment {
+		problems = append(problems, "HeaderComment is set but OmitHeaderComment discards it")
+	}
+	if o.YearAsTime && o.TimeTypeMapping.Year.Name != "" {
+		problems = append(problems, "YearAsTime and TimeTypeMapping.Year both set the type of YEAR columns")
+	}
+
+	for i, b := range o.BytesTypes {
+		if b.Matcher == nil {
+			problems = append(problems, fmt.Sprintf("BytesTypes[%d] has no Matcher", i))
+		}
+		if !isIdentifier(b.Name) {
+			problems = append(problems, fmt.Sprintf("BytesTypes[%d] has an invalid Name %q", i, b.Name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid generate options: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// typeQualifier returns the package qualifier of a Go type, e.g. "geo" for `*geo.Point`,
+// or an empty string for unqualified types.
+func typeQualifier(goType string) string {
+	goType = strings.TrimLeft(goType, "*[]")
+	if pos := strings.Index(goType, "."); pos > 0 {
+		return goType[:pos]
+	}
+	return ""
+}
+
+// dialect returns the dialect used to map types, defaulting to MySQL.
+func (o GenerateOptions) dialect() Dialect {
+	if o.Dialect == nil {
+		return MySQL
+	}
+	return o.Dialect
+}
+
+// structName returns the name of the struct generated for a table.
+func (o GenerateOptions) structName(tableName string) string {
+	suffix := o.StructSuffix
+	if o.OmitStructSuffix {
+		suffix = ""
+	} else if suffix == "" {
+		suffix = "Data"
+	}
+	return o.structBaseName(tableName) + suffix
+}
+
+// structBaseName returns the name of the struct generated for a table without its suffix.
+func (o GenerateOptions) structBaseName(tableName string) string {
+	for _, prefix := range o.TrimTablePrefix {
+		if prefix != "" && strings.HasPrefix(tableName, prefix) {
+			tableName = strings.TrimPrefix(tableName, prefix)
+			break
+		}
+	}
+
+	if o.Singularize {
+		tableName = Singularize(tableName)
+	}
+
+	return o.camelize(tableName)
+}
+
+// columnTypeName returns the name of the type generated for an ENUM or SET column when
+// WithEnumTypes or WithSetTypes is set.
+func (o GenerateOptions) columnTypeName(tableName string, column string) string {
+	return o.structBaseName(tableName) + o.camelize(column)
+}
+
+// hasNamedType reports whether a column is rendered with a generated named type.
+func (o GenerateOptions) hasNamedType(t TableDescriptor) bool {
+	return (o.WithEnumTypes && isEnum(t)) || (o.WithSetTypes && isSet(t))
+}
+
+// bytesType returns the name of the BytesTypes entry matching a binary column, or an
+// empty string if the column keeps the plain `[]byte` type.
+func (o GenerateOptions) bytesType(t TableDescriptor) string {
+
+	if len(o.BytesTypes) == 0 || o.goType(t) != "[]byte" {
+		return ""
+	}
+	for _, b := range o.BytesTypes {
+		if b.Matcher != nil && b.Matcher.MatchString(t.Field) {
+			return b.Name
+		}
+	}
+
+	return ""
+}
+
+// fieldType returns the Go type of a struct field, which is the generated named type of
+// ENUM and SET columns when WithEnumTypes or WithSetTypes is set, the BytesTypes entry of
+// binary columns, and the result of goType otherwise.
+func (o GenerateOptions) fieldType(tableName string, t TableDescriptor) string {
+
+	if name := o.bytesType(t); name != "" {
+		return name
+	}
+
+	if o.hasNamedType(t) {
+		result := o.columnTypeName(tableName, t.Field)
+		if isNullable(t) {
+			result = "*" + result
+		}
+		return result
+	}
+
+	return o.goType(t)
+}
+
+// namedTypes returns the declarations of the NullTime type, when used, of the named
+// types of every ENUM and SET column of the given tables, and of the BytesTypes used by
+// their binary columns. A type generated with the same name and values for several
+// columns is only declared once.
+func (o GenerateOptions) namedTypes(descriptors map[string][]TableDescriptor) (string, error) {
+
+	result := strings.Builder{}
+	if o.usesNullTime(descriptors) {
+		result.WriteString(CreateNullTimeType())
+		result.WriteString("\n\n")
+	}
+
+	declared := make(map[string]string)
+	for _, tableName := range sortedTableNames(descriptors) {
+		for _, t := range OrderFields(descriptors[tableName], o.FieldOrder) {
+			if name := o.bytesType(t); name != "" {
+				if _, ok := declared[name]; !ok {
+					declared[name] = "[]byte"
+					result.WriteString(CreateBytesType(name))
+					result.WriteString("\n\n")
+				}
+				continue
+			}
+			if !o.hasNamedType(t) {
+				continue
+			}
+
+			typeName := o.columnTypeName(tableName, t.Field)
+			values := baseType(t.Type) + "\x00" + strings.Join(parseTypeValues(t.Type), "\x00")
+			if previous, ok := declared[typeName]; ok {
+				if previous != values {
+					return "", fmt.Errorf("type %s is generated for columns with different values", typeName)
+				}
+				continue
+			}
+			declared[typeName] = values
+
+			var code string
+			var err error
+			if isEnum(t) {
+				code, err = CreateEnumType(t, typeName)
+			} else {
+				code, err = CreateSetType(t, typeName)
+			}
+			if err != nil {
+				return "", err
+			}
+			result.WriteString(code)
+			result.WriteString("\n\n")
+		}
+	}
+
+	return result.String(), nil
+}
+
+// usesNullTime reports whether a field of the given tables is rendered as NullTime.
+func (o GenerateOptions) usesNullTime(descriptors map[string][]TableDescriptor) bool {
+	if !o.WithNullTime {
+		return false
+	}
+	for tableName, tt := range descriptors {
+		for _, t := range tt {
+			if o.fieldType(tableName, t) == nullTimeType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// constructor returns the `New<Struct>` function of a table.
+func (o GenerateOptions) constructor(tt []TableDescriptor, tableName string) string {
+
+	name := o.structName(tableName)
+
+	params := make([]string, 0, len(tt))
+	fields := make([][2]string, 0, len(tt))
+	width := 0
+	for _, t := range OrderFields(tt, o.FieldOrder) {
+		if isNullable(t) || IsAutoIncrement(t) {
+			continue
+		}
+		param := o.paramName(t.Field)
+		params = append(params, param+" "+o.fieldType(tableName, t))
+		field := o.fieldName(t.Field)
+		fields = append(fields, [2]string{field, param})
+		if len(field) > width {
+			width = len(field)
+		}
+	}
+
+	result := strings.Builder{}
+	result.WriteString(fmt.Sprintf("// New%s returns a %s holding the required columns of the %s table.\n", name, name, tableName))
+	result.WriteString(fmt.Sprintf("func New%s(%s) %s {\n", name, strings.Join(params, ", "), name))
+	result.WriteString(fmt.Sprintf("\treturn %s{", name))
+	if len(fields) > 0 {
+		result.WriteString("\n")
+		for _, f := range fields {
+			result.WriteString(fmt.Sprintf("\t\t%-*s %s,\n", width+1, f[0]+":", f[1]))
+		}
+		result.WriteString("\t")
+	}
+	result.WriteString("}\n}")
+
+	return result.String()
+}
+
+// paramName returns a camelCase parameter name for a column that does not clash with Go
+// keywords or with the packages referenced by generated code.
+func (o GenerateOptions) paramName(column string) string {
+	result := SanitizeIdentifier(CamelizeWithInitialisms(toWords(column), false, o.initialisms()))
+	if _, ok := knownImports[result]; ok || result == "driver" || result == "fmt" || result == "strings" {
+		result += "_"
+	}
+
+	return result
+}
+
+// columnConstants returns the constant block declaring the column names of a table, in
+// schema order. Columns whose names camelize to the same identifier get a constant named
+// after their position instead, so every identifier is unique.
+func (o GenerateOptions) columnConstants(tt []TableDescriptor, tableName string) string {
+
+	prefix := o.structBaseName(tableName) + "Col"
+
+	names := make([]string, 0, len(tt))
+	used := make(map[string]bool)
+	width := 0
+	for i, t := range tt {
+		name := prefix + o.camelize(t.Field)
+		if used[name] {
+			name = fmt.Sprintf("%s%d", prefix, i+1)
+		}
+		used[name] = true
+		names = append(names, name)
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+
+	result := strings.Builder{}
+	result.WriteString(fmt.Sprintf("// Column names of the %s table.\n", tableName))
+	result.WriteString("const (\n")
+	for i, t := range tt {
+		result.WriteString(fmt.Sprintf("\t%-*s = %q\n", width, names[i], t.Field))
+	}
+	result.WriteString(")")
+
+	return result.String()
+}
+
+// queryHelpers returns the constant block declaring the CRUD statements of a table.
+func (o GenerateOptions) queryHelpers(tt []TableDescriptor, tableName string) string {
+
+	prefix := o.structBaseName(tableName)
+	table := QuoteIdentifier(tableName)
+
+	columns := make([]string, 0, len(tt))
+	inserted := make([]string, 0, len(tt))
+	assignments := make([]string, 0, len(tt))
+	for _, t := range tt {
+		column := QuoteIdentifier(t.Field)
+		columns = append(columns, column)
+		if !IsAutoIncrement(t) {
+			inserted = append(inserted, column)
+		}
+		if t.Key != "PRI" {
+			assignments = append(assignments, column+" = ?")
+		}
+	}
+
+	conditions := make([]string, 0)
+	for _, key := range PrimaryKeys(tt) {
+		conditions = append(conditions, QuoteIdentifier(key)+" = ?")
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(inserted)), ", ")
+	where := strings.Join(conditions, " AND ")
+
+	queries := [][2]string{
+		{prefix + "SelectQuery", fmt.Sprintf("SELECT %s FROM %s", strings.Join(columns, ", "), table)},
+	}
+	if !isView(tt) {
+		queries = append(queries, [2]string{prefix + "InsertQuery", fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(inserted, ", "), placeholders)})
+		if len(conditions) > 0 && len(assignments) > 0 {
+			queries = append(queries, [2]string{prefix + "UpdateQuery", fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(assignments, ", "), where)})
+		}
+		if len(conditions) > 0 {
+			queries = append(queries, [2]string{prefix + "DeleteQuery", fmt.Sprintf("DELETE FROM %s WHERE %s", table, where)})
+		}
+	}
+
+	width := 0
+	for _, q := range queries {
+		if len(q[0]) > width {
+			width = len(q[0])
+		}
+	}
+
+	result := strings.Builder{}
+	result.WriteString(fmt.Sprintf("// Statements of the %s table.\n", tableName))
+	result.WriteString("const (\n")
+	for _, q := range queries {
+		result.WriteString(fmt.Sprintf("\t%-*s = %q\n", width, q[0], q[1]))
+	}
+	result.WriteString(")")
+
+	return result.String()
+}
+
+// fieldName returns the name of the struct field generated for a column. Columns named
+// after a Go keyword get KeywordFieldSuffix appended, and fields named after a method
+// generated for the struct get an underscore appended, since a struct cannot have a
+// field and a method with the same name.
+func (o GenerateOptions) fieldName(column string) string {
+	result := o.camelize(column)
+	if o.KeywordFieldSuffix != "" && goKeywords[strings.ToLower(column)] {
+		result = SanitizeIdentifier(result + o.KeywordFieldSuffix)
+	}
+	if o.isMethodName(result) {
+		result += "_"
+	}
+	return result
+}
+
+// isMethodName reports whether name is the name of a method generated for structs: the
+// methods of WithTableNameMethod and WithStringer when they are set, and LogValue,
+// FieldColumnMap and IsView, which are reserved whatever the options since they can be
+// generated separately or depend on the table.
+func (o GenerateOptions) isMethodName(name string) bool {
+	switch name {
+	case "LogValue", "FieldColumnMap", "IsView":
+		return true
+	case "TableName":
+		return o.WithTableNameMethod
+	case "String":
+		return o.WithStringer
+	}
+	return false
+}
+
+// camelize converts a snake_case name into a valid PascalCase identifier using the
+// configured initialisms.
+func (o GenerateOptions) camelize(name string) string {
+	return SanitizeIdentifier(CamelizeWithInitialisms(toWords(name), true, o.initialisms()))
+}
+
+// initialisms returns the configured initialisms, defaulting to CommonInitialisms.
+func (o GenerateOptions) initialisms() []string {
+	if o.Initialisms == nil {
+		return CommonInitialisms
+	}
+	return o.Initialisms
+}
+
+// knownImports maps the package qualifiers used by generated types to their import paths.
+var knownImports = map[string]string{
+	"time":    "time",
+	"sql":     "database/sql",
+	"decimal": "github.com/shopspring/decimal",
+	"json":    "encoding/json",
+}
+
+// setTypeImports lists the import paths used by the methods of the types generated for
+// SET columns.
+var setTypeImports = []string{"database/sql/driver", "fmt", "strings"}
+
+// nullTimeImports lists the import paths used by the type generated by `CreateNullTimeType`.
+var nullTimeImports = []string{"database/sql/driver", "fmt", "time"}
+
+// bytesTypeImports lists the import paths used by the types generated by `CreateBytesType`.
+var bytesTypeImports = []string{"database/sql/driver", "encoding/hex", "fmt"}
+
+// imports returns the sorted, deduplicated import paths required by the given tables.
+func (o GenerateOptions) imports(descriptors map[string][]TableDescriptor) []string {
+
+	seen := make(map[string]bool)
+	if o.WithStringer && len(descriptors) > 0 {
+		seen["fmt"] = true
+		seen["strings"] = true
+	}
+	for tableName, tt := range descriptors {
+		for _, t := range tt {
+			if o.WithSetTypes && isSet(t) {
+				for _, importPath := range setTypeImports {
+					seen[importPath] = true
+				}
+			}
+			if o.WithEnumTypes && isEnum(t) {
+				seen["fmt"] = true
+			}
+			if o.fieldType(tableName, t) == nullTimeType {
+				for _, importPath := range nullTimeImports {
+					seen[importPath] = true
+				}
+			}
+			if o.bytesType(t) != "" {
+				for _, importPath := range bytesTypeImports {
+					seen[importPath] = true
+				}
+			}
+			if importPath, ok := o.CustomTypeImports[baseType(t.Type)]; ok && o.CustomTypeMap[baseType(t.Type)] != "" {
+				seen[importPath] = true
+			} else if custom, ok := o.TimeTypeMapping.lookup(baseType(t.Type)); ok && custom.ImportPath != "" {
+				seen[custom.ImportPath] = true
+			}
+			goType := strings.TrimLeft(o.fieldType(tableName, t), "*[]")
+			if pos := strings.Index(goType, "."); pos > 0 {
+				if importPath, ok := knownImports[goType[:pos]]; ok {
+					seen[importPath] = true
+				}
+			}
+		}
+	}
+
+	result := make([]string, 0, len(seen))
+	for importPath := range seen {
+		result = append(result, importPath)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// writeImports renders an import declaration for the given sorted paths, or nothing if
+// there are none. As goimports does, standard library paths come first and are separated
+// from the other paths, whose first element contains a dot, by an empty line.
+func writeImports(builder *strings.Builder, imports []string) {
+
+	switch len(imports) {
+	case 0:
+		return
+	case 1:
+		builder.WriteString("import \"" + imports[0] + "\"\n\n")
+		return
+	}
+
+	std := make([]string, 0, len(imports))
+	other := make([]string, 0)
+	for _, i := range imports {
+		if isStdlibImport(i) {
+			std = append(std, i)
+		} else {
+			other = append(other, i)
+		}
+	}
+
+	builder.WriteString("import (\n")
+	for _, i := range std {
+		builder.WriteString("\t\"" + i + "\"\n")
+	}
+	if len(std) > 0 && len(other) > 0 {
+		builder.WriteString("\n")
+	}
+	for _, i := range other {
+		builder.WriteString("\t\"" + i + "\"\n")
+	}
+	builder.WriteString(")\n\n")
+}
+
+// isStdlibImport reports whether an import path belongs to the standard library, whose
+// paths never have a dot in their first element.
+func isStdlibImport(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
+
+// isTinyIntOne reports whether a column type is `TINYINT(1)`, signed or unsigned.
+func isTinyIntOne(dbType string) bool {
+	cleanType := strings.ToUpper(dbType)
+	cleanType = strings.ReplaceAll(cleanType, "UNSIGNED", "")
+	cleanType = strings.ReplaceAll(cleanType, " ", "")
+	return cleanType == "TINYINT(1)"
+}
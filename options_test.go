@@ -0,0 +1,567 @@
+package db2go
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGenerateOptionsValidate(t *testing.T) {
+
+	tests := []struct {
+		name string
+		opts GenerateOptions
+		// want lists substrings of the error, or is empty if the options are valid
+		want []string
+	}{
+		{name: "zero value", opts: GenerateOptions{}},
+		{
+			name: "custom type with import",
+			opts: GenerateOptions{
+				CustomTypeMap:     map[string]string{"GEOMETRY": "geo.Point"},
+				CustomTypeImports: map[string]string{"GEOMETRY": "example.com/geo"},
+			},
+		},
+		{
+			name: "custom type from a known package",
+			opts: GenerateOptions{CustomTypeMap: map[string]string{"DECIMAL": "decimal.Decimal", "JSON": "[]json.RawMessage"}},
+		},
+		{
+			name: "custom type without import",
+			opts: GenerateOptions{CustomTypeMap: map[string]string{"GEOMETRY": "*geo.Point"}},
+			want: []string{"CustomTypeMap maps GEOMETRY to *geo.Point"},
+		},
+		{
+			name: "time type without import",
+			opts: GenerateOptions{TimeTypeMapping: TimeTypeMapping{Date: GoType{Name: "civil.Date"}}},
+			want: []string{"TimeTypeMapping.Date uses civil.Date"},
+		},
+		{
+			name: "suffix and omit suffix",
+			opts: GenerateOptions{StructSuffix: "Model", OmitStructSuffix: true},
+			want: []string{"StructSuffix", "OmitStructSuffix"},
+		},
+		{
+			name: "header and omit header",
+			opts: GenerateOptions{HeaderComment: "license", OmitHeaderComment: true},
+			want: []string{"HeaderComment", "OmitHeaderComment"},
+		},
+		{
+			name: "year as time and year mapping",
+			opts: GenerateOptions{YearAsTime: true, TimeTypeMapping: TimeTypeMapping{Year: GoType{Name: "int"}}},
+			want: []string{"YearAsTime", "TimeTypeMapping.Year"},
+		},
+		{
+			name: "bytes type",
+			opts: GenerateOptions{BytesTypes: []BytesType{{Matcher: regexp.MustCompile("hash$"), Name: "HashBytes"}}},
+		},
+		{
+			name: "bytes type without matcher or valid name",
+			opts: GenerateOptions{BytesTypes: []BytesType{{Name: "HashBytes"}, {Matcher: regexp.MustCompile("uuid"), Name: "uuid bytes"}}},
+			want: []string{"BytesTypes[0] has no Matcher", `BytesTypes[1] has an invalid Name "uuid bytes"`},
+		},
+		{
+			name: "every problem is reported",
+			opts: GenerateOptions{StructSuffix: "Row", OmitStructSuffix: true, HeaderComment: "x", OmitHeaderComment: true},
+			want: []string{"StructSuffix", "HeaderComment"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() = nil, want an error mentioning %q", tt.want)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("Validate() = %q, want it to mention %q", err, w)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateAllTablesStructToWriterValidatesOptions(t *testing.T) {
+
+	descriptors := map[string][]TableDescriptor{
+		"places": {{Field: "location", Type: "geometry", Null: "NO"}},
+	}
+	opts := GenerateOptions{CustomTypeMap: map[string]string{"GEOMETRY": "geo.Point"}}
+
+	out := strings.Builder{}
+	if err := CreateAllTablesStructToWriter(&out, "models", descriptors, opts); err == nil {
+		t.Fatal("CreateAllTablesStructToWriter() = nil, want the Validate error")
+	}
+	if out.Len() != 0 {
+		t.Errorf("CreateAllTablesStructToWriter() wrote %q, want nothing", out.String())
+	}
+}
+
+func TestWriteImports(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		imports []string
+		want    string
+	}{
+		{name: "none", want: ""},
+		{name: "single", imports: []string{"time"}, want: "import \"time\"\n\n"},
+		{name: "single third party", imports: []string{"github.com/shopspring/decimal"}, want: "import \"github.com/shopspring/decimal\"\n\n"},
+		{
+			name:    "stdlib only",
+			imports: []string{"database/sql", "time"},
+			want:    "import (\n\t\"database/sql\"\n\t\"time\"\n)\n\n",
+		},
+		{
+			name:    "third party only",
+			imports: []string{"example.com/geo", "github.com/shopspring/decimal"},
+			want:    "import (\n\t\"example.com/geo\"\n\t\"github.com/shopspring/decimal\"\n)\n\n",
+		},
+		{
+			name:    "grouped",
+			imports: []string{"database/sql", "example.com/geo", "github.com/shopspring/decimal", "time"},
+			want:    "import (\n\t\"database/sql\"\n\t\"time\"\n\n\t\"example.com/geo\"\n\t\"github.com/shopspring/decimal\"\n)\n\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			builder := strings.Builder{}
+			writeImports(&builder, tc.imports)
+			if builder.String() != tc.want {
+				t.Errorf("writeImports(%v) =\n%s\nwant:\n%s", tc.imports, builder.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestGeneratedImportsAreGrouped(t *testing.T) {
+
+	descriptors := map[string][]TableDescriptor{
+		"orders": {
+			{Field: "id", Type: "int", Null: "NO", Key: "PRI"},
+			{Field: "total", Type: "decimal(10,2)", Null: "NO"},
+			{Field: "placed_at", Type: "datetime", Null: "YES"},
+			{Field: "note", Type: "varchar(20)", Null: "YES"},
+		},
+	}
+	opts := GenerateOptions{DecimalType: DecimalShopspring, NullStrategy: NullSQL}
+
+	out := strings.Builder{}
+	if err := CreateAllTablesStructToWriter(&out, "models", descriptors, opts); err != nil {
+		t.Fatalf("CreateAllTablesStructToWriter() = %v", err)
+	}
+
+	want := "import (\n\t\"database/sql\"\n\n\t\"github.com/shopspring/decimal\"\n)\n"
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("generated imports are not grouped, want:\n%s\ngot:\n%s", want, out.String())
+	}
+}
+
+func TestNullSQLIntegerTypes(t *testing.T) {
+
+	tests := []struct {
+		dbType string
+		want   string
+	}{
+		{"bigint", "sql.NullInt64"},
+		{"int", "sql.NullInt32"},
+		{"mediumint", "sql.NullInt32"},
+		{"smallint", "sql.NullInt16"},
+		{"tinyint", "sql.Null[int8]"},
+		{"bigint unsigned", "sql.Null[uint64]"},
+		{"int unsigned", "sql.Null[uint32]"},
+		{"smallint unsigned", "sql.Null[uint16]"},
+		{"tinyint unsigned", "sql.NullByte"},
+	}
+
+	opts := GenerateOptions{NullStrategy: NullSQL}
+	columns := make([]TableDescriptor, 0, len(tests))
+	for i, tc := range tests {
+		column := TableDescriptor{Field: fmt.Sprintf("c%d", i), Type: tc.dbType, Null: "YES"}
+		columns = append(columns, column)
+		t.Run(tc.dbType, func(t *testing.T) {
+			if got := opts.goType(column); got != tc.want {
+				t.Errorf("goType(%s) = %s, want %s", tc.dbType, got, tc.want)
+			}
+		})
+	}
+
+	src := generateFile(t, map[string][]TableDescriptor{"counters": columns}, opts)
+	fields := structFields(t, checkSource(t, src), "CountersData")
+	for i, tc := range tests {
+		if got := fields[fmt.Sprintf("C%d", i)]; got != tc.want {
+			t.Errorf("field of %s = %s, want %s", tc.dbType, got, tc.want)
+		}
+	}
+}
+
+func TestDefaultTags(t *testing.T) {
+
+	value := func(s string) *string { return &s }
+
+	tests := []struct {
+		name   string
+		column TableDescriptor
+		// want is the value of the default tag, or nil if the field has no default tag
+		want *string
+	}{
+		{name: "numeric", column: TableDescriptor{Type: "int", Null: "NO", Default: value("0")}, want: value("0")},
+		{name: "decimal", column: TableDescriptor{Type: "decimal(10,2)", Null: "NO", Default: value("1.50")}, want: value("1.50")},
+		{name: "string", column: TableDescriptor{Type: "varchar(20)", Null: "NO", Default: value("pending")}, want: value("pending")},
+		{name: "quoted string", column: TableDescriptor{Type: "varchar(20)", Null: "NO", Default: value(`say "hi"`)}, want: value(`say "hi"`)},
+		{name: "empty string", column: TableDescriptor{Type: "varchar(20)", Null: "NO", Default: value("")}, want: value("")},
+		{name: "no default", column: TableDescriptor{Type: "int", Null: "YES"}},
+		{name: "current timestamp", column: TableDescriptor{Type: "timestamp", Null: "NO", Default: value("CURRENT_TIMESTAMP")}},
+		{name: "current timestamp with precision", column: TableDescriptor{Type: "datetime(6)", Null: "NO", Default: value("CURRENT_TIMESTAMP(6)")}},
+		{name: "mariadb current timestamp", column: TableDescriptor{Type: "timestamp", Null: "NO", Default: value("current_timestamp()")}},
+		{name: "now", column: TableDescriptor{Type: "datetime", Null: "NO", Default: value("NOW()")}},
+		{name: "function call", column: TableDescriptor{Type: "varchar(36)", Null: "NO", Default: value("uuid()")}},
+		{name: "parenthesized expression", column: TableDescriptor{Type: "int", Null: "NO", Default: value("(rand() * 10)")}},
+		{
+			name:   "default generated",
+			column: TableDescriptor{Type: "json", Null: "NO", Default: value("json_array()"), Extra: "DEFAULT_GENERATED"},
+		},
+		{
+			name:   "default generated on update",
+			column: TableDescriptor{Type: "timestamp", Null: "NO", Default: value("now"), Extra: "DEFAULT_GENERATED on update CURRENT_TIMESTAMP"},
+		},
+	}
+
+	opts := GenerateOptions{WithDefaultTags: true}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+
+			tc.column.Field = "value"
+			tag := reflect.StructTag(opts.structTags(tc.column))
+			got, ok := tag.Lookup("default")
+
+			switch {
+			case tc.want == nil && ok:
+				t.Errorf("structTags() = %s, want no default tag", tag)
+			case tc.want != nil && (!ok || got != *tc.want):
+				t.Errorf("structTags() = %s, want the default tag %q", tag, *tc.want)
+			}
+		})
+	}
+}
+
+func TestGoType(t *testing.T) {
+
+	column := func(dbType, null, key string) TableDescriptor {
+		return TableDescriptor{Field: "value", Type: dbType, Null: null, Key: key}
+	}
+
+	tests := []struct {
+		name   string
+		column TableDescriptor
+		opts   GenerateOptions
+		want   string
+	}{
+		// Nullability and primary keys
+		{name: "not null", column: column("varchar(20)", "NO", ""), want: "string"},
+		{name: "nullable", column: column("varchar(20)", "YES", ""), want: "*string"},
+		{name: "nullable primary key", column: column("int", "YES", "PRI"), want: "int32"},
+		{name: "nullable primary key with NullSQL", column: column("int", "YES", "PRI"), opts: GenerateOptions{NullStrategy: NullSQL}, want: "int32"},
+		{name: "nullable string with NullSQL", column: column("varchar(20)", "YES", ""), opts: GenerateOptions{NullStrategy: NullSQL}, want: "sql.NullString"},
+		{name: "nullable blob", column: column("blob", "YES", ""), want: "[]byte"},
+
+		// Integers
+		{name: "tinyint", column: column("tinyint(4)", "NO", ""), want: "int8"},
+		{name: "smallint", column: column("smallint", "NO", ""), want: "int16"},
+		{name: "mediumint", column: column("mediumint", "NO", ""), want: "int32"},
+		{name: "int", column: column("int(11)", "NO", ""), want: "int32"},
+		{name: "bigint", column: column("bigint(20)", "NO", ""), want: "int64"},
+		{name: "tinyint unsigned", column: column("tinyint(3) unsigned", "NO", ""), want: "uint8"},
+		{name: "smallint unsigned", column: column("smallint unsigned", "NO", ""), want: "uint16"},
+		{name: "mediumint unsigned", column: column("mediumint unsigned", "NO", ""), want: "uint32"},
+		{name: "int unsigned", column: column("INT UNSIGNED", "NO", ""), want: "uint32"},
+		{name: "bigint unsigned", column: column("bigint(20) unsigned", "YES", ""), want: "*uint64"},
+
+		// TINYINT(1) as bool
+		{name: "tinyint(1)", column: column("tinyint(1)", "NO", ""), want: "int8"},
+		{name: "tinyint(1) as bool", column: column("tinyint(1)", "NO", ""), opts: GenerateOptions{TinyIntAsBool: true}, want: "bool"},
+		{name: "nullable tinyint(1) as bool", column: column("tinyint(1)", "YES", ""), opts: GenerateOptions{TinyIntAsBool: true}, want: "*bool"},
+		{name: "unsigned tinyint(1) as bool", column: column("tinyint(1) unsigned", "NO", ""), opts: GenerateOptions{TinyIntAsBool: true}, want: "bool"},
+		{name: "tinyint(4) with bool option", column: column("tinyint(4)", "NO", ""), opts: GenerateOptions{TinyIntAsBool: true}, want: "int8"},
+
+		// Decimals and floats
+		{name: "decimal", column: column("decimal(10,2)", "NO", ""), want: "float64"},
+		{name: "decimal as string", column: column("decimal(10,2)", "NO", ""), opts: GenerateOptions{DecimalType: DecimalString}, want: "string"},
+		{name: "numeric as string", column: column("numeric(10,2)", "YES", ""), opts: GenerateOptions{DecimalType: DecimalString}, want: "*string"},
+		{name: "decimal as shopspring", column: column("decimal(10,2)", "NO", ""), opts: GenerateOptions{DecimalType: DecimalShopspring}, want: "decimal.Decimal"},
+		{
+			name:   "nullable shopspring with NullSQL",
+			column: column("decimal(10,2)", "YES", ""),
+			opts:   GenerateOptions{DecimalType: DecimalShopspring, NullStrategy: NullSQL},
+			want:   "decimal.NullDecimal",
+		},
+		{name: "float", column: column("float", "NO", ""), want: "float64"},
+		{name: "float32", column: column("float", "NO", ""), opts: GenerateOptions{PreserveFloatWidth: true}, want: "float32"},
+		{name: "double stays float64", column: column("double", "NO", ""), opts: GenerateOptions{PreserveFloatWidth: true}, want: "float64"},
+		{
+			name:   "nullable float32 with NullSQL",
+			column: column("float", "YES", ""),
+			opts:   GenerateOptions{PreserveFloatWidth: true, NullStrategy: NullSQL},
+			want:   "sql.Null[float32]",
+		},
+
+		// JSON
+		{name: "json", column: column("json", "NO", ""), want: "json.RawMessage"},
+		{name: "json as bytes", column: column("json", "YES", ""), opts: GenerateOptions{JSONType: JSONBytes}, want: "[]byte"},
+		{name: "json as string", column: column("json", "NO", ""), opts: GenerateOptions{JSONType: JSONString}, want: "string"},
+		{name: "nullable json as string", column: column("json", "YES", ""), opts: GenerateOptions{JSONType: JSONString}, want: "*string"},
+
+		// Dates and times
+		{name: "datetime", column: column("datetime", "NO", ""), want: "time.Time"},
+		{name: "date", column: column("date", "YES", ""), want: "*time.Time"},
+		{name: "year", column: column("year(4)", "NO", ""), want: "int16"},
+		{name: "year as time", column: column("year", "YES", ""), opts: GenerateOptions{YearAsTime: true}, want: "*time.Time"},
+		{
+			name:   "custom date type",
+			column: column("date", "NO", ""),
+			opts:   GenerateOptions{TimeTypeMapping: TimeTypeMapping{Date: GoType{Name: "civil.Date", ImportPath: "cloud.google.com/go/civil"}}},
+			want:   "civil.Date",
+		},
+		{
+			name:   "nullable custom time type",
+			column: column("time", "YES", ""),
+			opts:   GenerateOptions{TimeTypeMapping: TimeTypeMapping{Time: GoType{Name: "civil.Time", ImportPath: "cloud.google.com/go/civil"}}},
+			want:   "*civil.Time",
+		},
+		{
+			name:   "custom date type leaves datetime",
+			column: column("datetime", "NO", ""),
+			opts:   GenerateOptions{TimeTypeMapping: TimeTypeMapping{Date: GoType{Name: "civil.Date", ImportPath: "cloud.google.com/go/civil"}}},
+			want:   "time.Time",
+		},
+
+		// Custom type map
+		{name: "unknown type", column: column("geometry", "NO", ""), want: "interface{}"},
+		{
+			name:   "custom unknown type",
+			column: column("geometry", "YES", ""),
+			opts:   GenerateOptions{CustomTypeMap: map[string]string{"GEOMETRY": "geo.Point"}},
+			want:   "*geo.Point",
+		},
+		{
+			name:   "custom type overriding a known one",
+			column: column("varchar(36)", "NO", ""),
+			opts:   GenerateOptions{CustomTypeMap: map[string]string{"VARCHAR": "[]byte"}},
+			want:   "[]byte",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.opts.goType(tc.column); got != tc.want {
+				t.Errorf("goType(%s %s) = %s, want %s", tc.column.Type, tc.column.Null, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStructTags(t *testing.T) {
+
+	value := func(s string) *string { return &s }
+
+	id := TableDescriptor{Field: "id", Type: "bigint", Null: "NO", Key: "PRI", Extra: "auto_increment"}
+	userID := TableDescriptor{Field: "user_id", Type: "bigint", Null: "NO", Key: "MUL"}
+	nickname := TableDescriptor{Field: "nickname", Type: "varchar(20)", Null: "YES"}
+	status := TableDescriptor{Field: "status", Type: "varchar(20)", Null: "NO", Default: value("active")}
+	note := TableDescriptor{Field: "note", Type: "varchar(20)", Null: "NO", Default: value("")}
+	camel := TableDescriptor{Field: "UserID", Type: "bigint", Null: "NO"}
+
+	tests := []struct {
+		name   string
+		column TableDescriptor
+		opts   GenerateOptions
+		want   string
+	}{
+		{name: "no tags", column: userID, want: ""},
+		{name: "json keeps the column name", column: userID, opts: GenerateOptions{WithJSON: true}, want: `json:"user_id"`},
+		{name: "json camel case", column: userID, opts: GenerateOptions{WithJSON: true, JSONNaming: JSONNamingCamelCase}, want: `json:"userId"`},
+		{name: "json snake case", column: camel, opts: GenerateOptions{WithJSON: true, JSONNaming: JSONNamingSnakeCase}, want: `json:"user_id"`},
+		{name: "omitempty on nullable", column: nickname, opts: GenerateOptions{WithJSON: true, JSONOmitEmpty: true}, want: `json:"nickname,omitempty"`},
+		{name: "no omitempty on not null", column: userID, opts: GenerateOptions{WithJSON: true, JSONOmitEmpty: true}, want: `json:"user_id"`},
+		{name: "db", column: userID, opts: GenerateOptions{WithDBTags: true}, want: `db:"user_id"`},
+		{name: "gorm primary key", column: id, opts: GenerateOptions{WithGORMTags: true}, want: `gorm:"column:id;primaryKey;autoIncrement"`},
+		{name: "gorm not null", column: userID, opts: GenerateOptions{WithGORMTags: true}, want: `gorm:"column:user_id;not null"`},
+		{name: "gorm nullable", column: nickname, opts: GenerateOptions{WithGORMTags: true}, want: `gorm:"column:nickname"`},
+		{name: "gorm default", column: status, opts: GenerateOptions{WithGORMTags: true}, want: `gorm:"column:status;not null;default:active"`},
+		{name: "gorm empty default", column: note, opts: GenerateOptions{WithGORMTags: true}, want: `gorm:"column:note;not null;default:''"`},
+		{
+			name:   "every tag in order",
+			column: status,
+			opts:   GenerateOptions{WithDBTags: true, WithGORMTags: true, WithJSON: true, WithDefaultTags: true},
+			want:   `db:"status" gorm:"column:status;not null;default:active" json:"status" default:"active"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+
+			src := "package models\n\n" + CreateStructWithOptions([]TableDescriptor{tc.column}, "items", tc.opts)
+			file, err := parser.ParseFile(token.NewFileSet(), "generated.go", src, 0)
+			if err != nil {
+				t.Fatalf("generated code does not parse: %v\n%s", err, src)
+			}
+
+			got := ""
+			for _, tag := range fieldTags(t, file, "ItemsData") {
+				got = string(tag)
+			}
+			if got != tc.want {
+				t.Errorf("tags of %s = %s, want %s", tc.column.Field, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestImports(t *testing.T) {
+
+	column := func(dbType, null string) map[string][]TableDescriptor {
+		return map[string][]TableDescriptor{"items": {{Field: "value", Type: dbType, Null: null}}}
+	}
+
+	tests := []struct {
+		name        string
+		descriptors map[string][]TableDescriptor
+		opts        GenerateOptions
+		want        []string
+	}{
+		{name: "none", descriptors: column("int", "NO"), want: []string{}},
+		{name: "time", descriptors: column("datetime", "YES"), want: []string{"time"}},
+		{name: "json", descriptors: column("json", "NO"), want: []string{"encoding/json"}},
+		{name: "json as string", descriptors: column("json", "NO"), opts: GenerateOptions{JSONType: JSONString}, want: []string{}},
+		{name: "null wrapper", descriptors: column("varchar(20)", "YES"), opts: GenerateOptions{NullStrategy: NullSQL}, want: []string{"database/sql"}},
+		{name: "null time wrapper", descriptors: column("datetime", "YES"), opts: GenerateOptions{NullStrategy: NullSQL}, want: []string{"database/sql"}},
+		{
+			name:        "shopspring",
+			descriptors: column("decimal(10,2)", "NO"),
+			opts:        GenerateOptions{DecimalType: DecimalShopspring},
+			want:        []string{"github.com/shopspring/decimal"},
+		},
+		{
+			name:        "custom type",
+			descriptors: column("geometry", "NO"),
+			opts:        GenerateOptions{CustomTypeMap: map[string]string{"GEOMETRY": "geo.Point"}, CustomTypeImports: map[string]string{"GEOMETRY": "example.com/geo"}},
+			want:        []string{"example.com/geo"},
+		},
+		{
+			name:        "custom time type",
+			descriptors: column("date", "NO"),
+			opts:        GenerateOptions{TimeTypeMapping: TimeTypeMapping{Date: GoType{Name: "civil.Date", ImportPath: "cloud.google.com/go/civil"}}},
+			want:        []string{"cloud.google.com/go/civil"},
+		},
+		{name: "stringer", descriptors: column("int", "NO"), opts: GenerateOptions{WithStringer: true}, want: []string{"fmt", "strings"}},
+		{name: "stringer without tables", descriptors: map[string][]TableDescriptor{}, opts: GenerateOptions{WithStringer: true}, want: []string{}},
+		{name: "enum", descriptors: column("enum('a','b')", "NO"), opts: GenerateOptions{WithEnumTypes: true}, want: []string{"fmt"}},
+		{name: "set", descriptors: column("set('a','b')", "NO"), opts: GenerateOptions{WithSetTypes: true}, want: setTypeImports},
+		{
+			name:        "bytes type",
+			descriptors: column("binary(32)", "NO"),
+			opts:        GenerateOptions{BytesTypes: []BytesType{{Matcher: regexp.MustCompile("value"), Name: "ValueBytes"}}},
+			want:        bytesTypeImports,
+		},
+		{
+			name:        "bytes type not matching",
+			descriptors: column("binary(32)", "NO"),
+			opts:        GenerateOptions{BytesTypes: []BytesType{{Matcher: regexp.MustCompile("hash"), Name: "HashBytes"}}},
+			want:        []string{},
+		},
+		{
+			name: "several tables",
+			descriptors: map[string][]TableDescriptor{
+				"a": {{Field: "at", Type: "timestamp", Null: "NO"}},
+				"b": {{Field: "doc", Type: "json", Null: "NO"}, {Field: "on", Type: "date", Null: "NO"}},
+			},
+			want: []string{"encoding/json", "time"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.opts.imports(tc.descriptors); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("imports() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStructName(t *testing.T) {
+
+	tests := []struct {
+		table string
+		opts  GenerateOptions
+		want  string
+	}{
+		{table: "users", want: "UsersData"},
+		{table: "api_keys", want: "APIKeysData"},
+		{table: "users", opts: GenerateOptions{StructSuffix: "Model"}, want: "UsersModel"},
+		{table: "users", opts: GenerateOptions{OmitStructSuffix: true}, want: "Users"},
+		{table: "tbl_users", opts: GenerateOptions{TrimTablePrefix: []string{"tbl_"}}, want: "UsersData"},
+		{table: "app_users", opts: GenerateOptions{TrimTablePrefix: []string{"tbl_", "app_"}}, want: "UsersData"},
+		{table: "users", opts: GenerateOptions{TrimTablePrefix: []string{"tbl_"}}, want: "UsersData"},
+		{table: "users", opts: GenerateOptions{Singularize: true}, want: "UserData"},
+		{table: "order_categories", opts: GenerateOptions{Singularize: true, OmitStructSuffix: true}, want: "OrderCategory"},
+		{table: "tbl_people", opts: GenerateOptions{TrimTablePrefix: []string{"tbl_"}, Singularize: true, StructSuffix: "Row"}, want: "PersonRow"},
+		{table: "user-logs", want: "UserLogsData"},
+		{table: "2fa_codes", want: "Field2faCodesData"},
+		{table: "sku_prices", opts: GenerateOptions{Initialisms: append(CommonInitialisms, "SKU")}, want: "SKUPricesData"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.want, func(t *testing.T) {
+			if got := tc.opts.structName(tc.table); got != tc.want {
+				t.Errorf("structName(%q) = %s, want %s", tc.table, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHeaderComment(t *testing.T) {
+
+	tests := []struct {
+		name string
+		opts GenerateOptions
+		want string
+	}{
+		{name: "default", want: DefaultHeaderComment + "\n\n"},
+		{name: "omitted", opts: GenerateOptions{HeaderComment: "ignored", OmitHeaderComment: true}, want: ""},
+		{name: "comment", opts: GenerateOptions{HeaderComment: "// Copyright 2024 Example"}, want: "// Copyright 2024 Example\n\n"},
+		{
+			name: "plain lines",
+			opts: GenerateOptions{HeaderComment: "Copyright 2024 Example\n\nLicensed under the MIT license.\n"},
+			want: "// Copyright 2024 Example\n//\n// Licensed under the MIT license.\n\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.opts.headerComment(); got != tc.want {
+				t.Errorf("headerComment() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	src := generateFile(t, map[string][]TableDescriptor{"items": {{Field: "id", Type: "int", Null: "NO"}}},
+		GenerateOptions{HeaderComment: "Copyright 2024 Example"})
+	if !strings.HasPrefix(src, "// Copyright 2024 Example\n\npackage models\n") {
+		t.Errorf("generated file does not start with the header:\n%s", src)
+	}
+	if file := checkSource(t, src); file.Doc != nil {
+		t.Errorf("the header is the package documentation: %q", file.Doc.Text())
+	}
+}
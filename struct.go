@@ -2,8 +2,18 @@ package db2go
 
 import (
 	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"text/template"
 )
 
 // CreateAllTablesStructFile generates Go struct definitions for multiple database tables
@@ -24,24 +34,445 @@ import (
 // Notes:
 //   - The function uses the `CreateStruct` function to generate each struct definition.
 //   - The `writeToFile` helper function is used to write the generated code to the specified file.
-//   - Ensure the provided `filename` is writable, and the `packageName` is a valid Go package name.
+//   - Ensure the provided `filename` is writable. The function panics before writing
+//     anything if `packageName` is not a valid Go package name; see `ValidatePackageName`.
 //   - The file will contain all the structs, separated by newlines, under the specified package.
+//   - An existing file is overwritten.
 func CreateAllTablesStructFile(filename string, packageName string, descriptors map[string][]TableDescriptor, withJson bool) {
+	CreateAllTablesStructFileWithOptions(filename, descriptors, GenerateOptions{WithJSON: withJson, PackageName: packageName})
+}
+
+// CreateAllTablesStructFileWithOptions generates Go struct definitions for multiple database
+// tables, configured by a `GenerateOptions`, and writes them to a specified file.
+//
+// Parameters:
+//   - filename: string - The name of the file where the generated structs will be written.
+//   - descriptors: map[string][]TableDescriptor - A map where the keys are table names,
+//     and the values are slices of `TableDescriptor` objects containing metadata about
+//     the table columns.
+//   - opts: GenerateOptions - The generation options. `opts.PackageName` is used as the
+//     package clause of the file.
+//
+// Notes:
+//   - The function uses the `CreateStructWithOptions` function to generate each struct definition.
+//   - An import declaration is emitted for the packages used by the field types, such as
+//     "time" for `time.Time` or "database/sql" for `sql.NullString`.
+//   - The function panics if the package name is invalid or the file cannot be written;
+//     use `WriteStructsToFile` to receive the error instead.
+func CreateAllTablesStructFileWithOptions(filename string, descriptors map[string][]TableDescriptor, opts GenerateOptions) {
+	if err := WriteStructsToFile(filename, descriptors, opts); err != nil {
+		panic(err)
+	}
+}
+
+// WriteStructsToFile generates Go struct definitions for multiple database tables and
+// writes them to a specified file, returning an error instead of panicking.
+//
+// Parameters:
+//   - filename: string - The name of the file where the generated structs will be written.
+//   - descriptors: map[string][]TableDescriptor - A map where the keys are table names,
+//     and the values are slices of `TableDescriptor` objects containing metadata about
+//     the table columns.
+//   - opts: GenerateOptions - The generation options. `opts.PackageName` is used as the
+//     package clause of the file.
+//
+// Returns:
+//   - error: An error naming the file if it cannot be opened, parsed or written, an error
+//     if `opts.AppendToFile` is set and the file already declares the struct of a table,
+//     or any error returned by `CreateAllTablesStructToWriter`.
+//
+// Notes:
+//   - With `opts.AppendToFile`, a file that already has content only receives the named
+//     types and structs, without a second header comment, package clause or import
+//     declaration. Named types and their methods already declared in the file, like
+//     `NullTime` or an enum type shared by several tables, are not declared again. The
+//     imports already in the file must cover the appended code.
+func WriteStructsToFile(filename string, descriptors map[string][]TableDescriptor, opts GenerateOptions) error {
+
+	if opts.AppendToFile {
+		if info, err := os.Stat(filename); err == nil && info.Size() > 0 {
+			body, err := appendBody(filename, descriptors, opts)
+			if err != nil {
+				return err
+			}
+			return writeToFile(body, filename, true)
+		}
+	}
+
+	builder := strings.Builder{}
+	if err := CreateAllTablesStructToWriter(&builder, opts.PackageName, descriptors, opts); err != nil {
+		return err
+	}
+
+	return writeToFile(builder.String(), filename, opts.AppendToFile)
+}
+
+// appendBody returns the declarations appended to an existing file, leaving out those the
+// file already declares.
+func appendBody(filename string, descriptors map[string][]TableDescriptor, opts GenerateOptions) (string, error) {
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed reading %s: %w", filename, err)
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), filename, content, parser.SkipObjectResolution)
+	if err != nil {
+		return "", fmt.Errorf("failed parsing %s: %w", filename, err)
+	}
+
+	existing := make(map[string]bool)
+	for _, decl := range file.Decls {
+		for _, name := range declNames(decl) {
+			existing[name] = true
+		}
+	}
+
+	for _, tableName := range sortedTableNames(descriptors) {
+		if name := opts.structName(tableName); existing[name] {
+			return "", fmt.Errorf("%s already declares struct %s of table %s", filename, name, tableName)
+		}
+	}
+
+	body, err := generateBody(descriptors, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return dropDeclared(body, existing)
+}
+
+// dropDeclared removes from generated code the declarations whose names are all in
+// `existing`, along with their doc comments.
+func dropDeclared(body string, existing map[string]bool) (string, error) {
+
+	const prefix = "package p\n\n"
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", prefix+body, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return "", fmt.Errorf("failed parsing generated code: %w", err)
+	}
+
+	result := strings.Builder{}
+	last := 0
+	for _, decl := range file.Decls {
+		names := declNames(decl)
+		keep := len(names) == 0
+		for _, name := range names {
+			keep = keep || !existing[name]
+		}
+		if keep {
+			continue
+		}
+
+		start := decl.Pos()
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		}
+
+		from := fset.Position(start).Offset - len(prefix)
+		to := fset.Position(decl.End()).Offset - len(prefix)
+		result.WriteString(body[last:from])
+		last = to + len(body[to:]) - len(strings.TrimLeft(body[to:], "\n"))
+	}
+	result.WriteString(body[last:])
+
+	return result.String(), nil
+}
+
+// declNames returns the names declared by a top level declaration, methods being named
+// "<Receiver>.<Method>".
+func declNames(decl ast.Decl) []string {
+
+	names := make([]string, 0)
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv == nil || len(d.Recv.List) == 0 {
+			return append(names, d.Name.Name)
+		}
+		recv := d.Recv.List[0].Type
+		if star, ok := recv.(*ast.StarExpr); ok {
+			recv = star.X
+		}
+		if ident, ok := recv.(*ast.Ident); ok {
+			names = append(names, ident.Name+"."+d.Name.Name)
+		}
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, s.Name.Name)
+			case *ast.ValueSpec:
+				for _, name := range s.Names {
+					names = append(names, name.Name)
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+// CreateAllTablesStructToWriter generates Go struct definitions for multiple database tables
+// and writes them to an `io.Writer`.
+//
+// This function produces exactly the content written by `WriteStructsToFile`, so generated
+// code can be streamed to a buffer, an HTTP response or standard output. Structs are
+// emitted in table name order, so the output is deterministic. The output starts with
+// `opts.HeaderComment`, by default the standard generated code marker.
+//
+// Parameters:
+//   - w: io.Writer - The destination of the generated code.
+//   - packageName: string - The name of the Go package to include at the top of the output.
+//     It takes precedence over `opts.PackageName`.
+//   - descriptors: map[string][]TableDescriptor - A map where the keys are table names,
+//     and the values are slices of `TableDescriptor` objects containing metadata about
+//     the table columns.
+//   - opts: GenerateOptions - The generation options.
+//
+// Returns:
+//   - error: An error if `packageName` is invalid, the error returned by `opts.Validate`,
+//     the error returned by the writer, if any, an error if `opts.StructTemplate` cannot
+//     be parsed or executed, an error if two tables generate the same struct name, which
+//     `opts.TrimTablePrefix` and `opts.Singularize` can cause, or an error if
+//     `opts.WithEnumTypes` or `opts.WithSetTypes` generates the same type name for columns
+//     with different values. Nothing is written if generation fails.
+func CreateAllTablesStructToWriter(w io.Writer, packageName string, descriptors map[string][]TableDescriptor, opts GenerateOptions) error {
+
+	if err := ValidatePackageName(packageName); err != nil {
+		return err
+	}
+
+	body, err := generateBody(descriptors, opts)
+	if err != nil {
+		return err
+	}
 
 	builder := strings.Builder{}
 
+	builder.WriteString(opts.headerComment())
+
 	builder.WriteString("package ")
 	builder.WriteString(packageName)
 	builder.WriteString("\n\n")
 
-	for k, v := range descriptors {
+	writeImports(&builder, opts.imports(descriptors))
+
+	builder.WriteString(body)
+
+	if _, err := io.WriteString(w, builder.String()); err != nil {
+		return fmt.Errorf("failed writing generated structs: %w", err)
+	}
+
+	return nil
+}
+
+// knownOSArch lists the GOOS and GOARCH values that, as the last underscore separated
+// element of a file name, restrict the file to a platform.
+var knownOSArch = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+	"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+	"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+	"windows": true, "zos": true,
+	"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+	"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true,
+	"mips64le": true, "mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true,
+	"ppc64le": true, "riscv": true, "riscv64": true, "s390": true, "s390x": true,
+	"sparc": true, "sparc64": true, "wasm": true,
+}
+
+// tableFileName returns the name of the file generated for a table by
+// CreateStructFilesPerTable, the snake_case table name with the `.go` extension. Names the
+// go tool would ignore or treat specially are made safe: leading underscores are removed,
+// and `_model` is appended to names ending in `_test` or in a GOOS or GOARCH value, e.g.
+// `users_test_model.go` rather than a test file.
+func tableFileName(tableName string) string {
 
-		builder.WriteString(CreateStruct(v, k, withJson))
+	name := strings.TrimLeft(ToSnakeCase(toWords(tableName)), "_")
+	if name == "" {
+		name = "table"
+	}
+
+	if pos := strings.LastIndex(name, "_"); pos > 0 && (name[pos+1:] == "test" || knownOSArch[name[pos+1:]]) {
+		name += "_model"
+	}
+
+	return name + ".go"
+}
+
+// generateBody returns the declarations of a generated file, the named types followed by
+// the structs, without the header comment, package clause and imports.
+func generateBody(descriptors map[string][]TableDescriptor, opts GenerateOptions) (string, error) {
+
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+	if err := checkStructNames(descriptors, opts); err != nil {
+		return "", err
+	}
+
+	builder := strings.Builder{}
+
+	namedTypes, err := opts.namedTypes(descriptors)
+	if err != nil {
+		return "", err
+	}
+	builder.WriteString(namedTypes)
+
+	for _, k := range sortedTableNames(descriptors) {
+
+		code, err := createStruct(descriptors[k], k, opts)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(code)
 		builder.WriteString("\n\n")
 
 	}
 
-	writeToFile(builder.String(), filename)
+	return builder.String(), nil
+}
+
+// CreateStructFilesPerTable generates Go struct definitions for multiple database tables
+// and writes each of them to its own file.
+//
+// Each file is named after its table in snake_case, e.g. `users.go` for the `users`
+// table, and is a complete Go source file with its own package clause and imports, as
+// produced by `CreateAllTablesStructToWriter` for that single table. Names the go tool
+// would ignore or treat specially are made safe: leading underscores and dots are
+// removed, and `_model` is appended to names ending in `_test` or in a GOOS or GOARCH
+// value, e.g. `users_test_model.go` for the `users_test` table.
+//
+// Parameters:
+//   - dir: string - The directory where the files are written. It must already exist.
+//   - packageName: string - The name of the Go package to include at the top of every file.
+//   - descriptors: map[string][]TableDescriptor - A map where the keys are table names,
+//     and the values are slices of `TableDescriptor` objects containing metadata about
+//     the table columns.
+//   - opts: GenerateOptions - The generation options. `opts.AppendToFile` is ignored.
+//
+// Returns:
+//   - error: An error if `packageName` is invalid, if two tables map to the same file
+//     name or generate the same struct name, or naming the file that could not be
+//     generated or written. Files are written in table name order, so the files of the
+//     tables before the failing one are left in place.
+func CreateStructFilesPerTable(dir string, packageName string, descriptors map[string][]TableDescriptor, opts GenerateOptions) error {
+
+	if err := ValidatePackageName(packageName); err != nil {
+		return err
+	}
+	if err := checkStructNames(descriptors, opts); err != nil {
+		return err
+	}
+
+	tables := sortedTableNames(descriptors)
+
+	filenames := make(map[string]string, len(tables))
+	for _, tableName := range tables {
+		filename := tableFileName(tableName)
+		if other, ok := filenames[filename]; ok {
+			return fmt.Errorf("tables %s and %s are both written to %s", other, tableName, filename)
+		}
+		filenames[filename] = tableName
+	}
+
+	for _, tableName := range tables {
+		filename := filepath.Join(dir, tableFileName(tableName))
+
+		builder := strings.Builder{}
+		single := map[string][]TableDescriptor{tableName: descriptors[tableName]}
+		if err := CreateAllTablesStructToWriter(&builder, packageName, single, opts); err != nil {
+			return fmt.Errorf("failed generating %s: %w", filename, err)
+		}
+
+		if err := writeToFile(builder.String(), filename, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GenerateStructs generates the Go source of every table in memory.
+//
+// Each entry is a complete Go source file for a single table, with its header comment,
+// package clause, imports and struct, as written by `CreateStructFilesPerTable`, and
+// formatted with `go/format`. It is a building block for custom output pipelines.
+//
+// Parameters:
+//   - descriptors: map[string][]TableDescriptor - A map where the keys are table names,
+//     and the values are slices of `TableDescriptor` objects containing metadata about
+//     the table columns.
+//   - opts: GenerateOptions - The generation options. `opts.PackageName` is used as the
+//     package clause and must be a valid package name.
+//
+// Returns:
+//   - map[string]string: A map from each table name to its formatted Go source.
+//   - error: An error if `opts.PackageName` is invalid or two tables generate the same
+//     struct name, or naming the table whose source cannot be generated or formatted.
+func GenerateStructs(descriptors map[string][]TableDescriptor, opts GenerateOptions) (map[string]string, error) {
+
+	if err := ValidatePackageName(opts.PackageName); err != nil {
+		return nil, err
+	}
+	if err := checkStructNames(descriptors, opts); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(descriptors))
+	for _, tableName := range sortedTableNames(descriptors) {
+
+		builder := strings.Builder{}
+		single := map[string][]TableDescriptor{tableName: descriptors[tableName]}
+		if err := CreateAllTablesStructToWriter(&builder, opts.PackageName, single, opts); err != nil {
+			return nil, fmt.Errorf("failed generating table %s: %w", tableName, err)
+		}
+
+		formatted, err := format.Source([]byte(builder.String()))
+		if err != nil {
+			return nil, fmt.Errorf("failed formatting table %s: %w", tableName, err)
+		}
+
+		result[tableName] = string(formatted)
+	}
+
+	return result, nil
+}
+
+// ValidatePackageName checks that a name can be used in the package clause of generated files.
+//
+// Parameters:
+//   - name: string - The package name to check.
+//
+// Returns:
+//   - error: An error explaining why the name is invalid, or nil if it is valid.
+//
+// Example Usage:
+//   - ValidatePackageName("models") -> nil
+//   - ValidatePackageName("my-pkg") -> error, hyphens are not allowed
+//   - ValidatePackageName("123")    -> error, names cannot start with a digit
+//   - ValidatePackageName("type")   -> error, keywords are reserved
+func ValidatePackageName(name string) error {
+
+	switch {
+	case name == "":
+		return fmt.Errorf("package name is empty")
+	case name == "_":
+		return fmt.Errorf("package name cannot be the blank identifier")
+	case !isIdentifier(name):
+		return fmt.Errorf("package name %q is not a valid Go identifier", name)
+	case goKeywords[name]:
+		return fmt.Errorf("package name %q is a Go keyword", name)
+	}
+
+	return nil
 }
 
 // CreateStruct generates a Go struct definition based on the table descriptors.
@@ -65,52 +496,236 @@ func CreateAllTablesStructFile(filename string, packageName string, descriptors
 //
 // Notes:
 //   - The struct fields are formatted for alignment, ensuring consistent spacing.
-//   - JSON tags are included in the struct definition if `withJson` is set to `true`.
+//   - JSON tags are included in the struct definition if `withJson` is set to `true`, using
+//     the original column name. Use `CreateStructWithOptions` to select another naming.
 //   - Helper functions like `Camelize` and `getType` are expected to handle field name
 //     conversion and type determination, respectively.
 func CreateStruct(tt []TableDescriptor, tableName string, withJson bool) string {
+	return CreateStructWithOptions(tt, tableName, GenerateOptions{WithJSON: withJson})
+}
+
+// CreateStructDialect generates a Go struct definition using the type mapping of a dialect.
+//
+// This function behaves like `CreateStruct`, but maps column types with `d.MapType`, so
+// descriptors introspected from engines other than MySQL get the right Go types.
+//
+// Parameters:
+//   - tt: []TableDescriptor - A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the table.
+//   - tableName: string - The name of the table, used as the base name for the generated struct.
+//   - withJson: bool - A flag indicating whether to include JSON tags for the struct fields.
+//   - d: Dialect - The dialect whose type mapping is used.
+//
+// Returns:
+//   - string: A string representation of the generated Go struct.
+func CreateStructDialect(tt []TableDescriptor, tableName string, withJson bool, d Dialect) string {
+	return CreateStructWithOptions(tt, tableName, GenerateOptions{WithJSON: withJson, Dialect: d})
+}
+
+// CreateStructWithOptions generates a Go struct definition configured by a `GenerateOptions`.
+//
+// This function is the common implementation behind `CreateStruct` and `CreateStructDialect`,
+// and the entry point for any generation setting beyond JSON tags.
+//
+// Parameters:
+//   - tt: []TableDescriptor - A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the table.
+//   - tableName: string - The name of the table, used as the base name for the generated struct.
+//   - opts: GenerateOptions - The generation options. The zero value is valid.
+//
+// Returns:
+//   - string: A string representation of the generated Go struct.
+//
+// Panics:
+//   - The function panics if the provided table descriptor slice is empty, if
+//     `opts.StructTemplate` cannot be parsed or executed, or if a row of
+//     `opts.LookupTables` cannot be written as literals.
+//
+// Notes:
+//   - Methods enabled by the options, like `TableName`, follow the struct declaration.
+//   - Structs of views, whose descriptors have `View` set, get an `IsView` method returning
+//     true, and their query helpers only include the SELECT statement.
+//   - Fields named after a generated method, like `IsView`, `LogValue`, `FieldColumnMap`,
+//     or `TableName` and `String` when their option is set, get an underscore appended.
+//   - Named ENUM and SET types are not included; they are declared by the functions
+//     writing complete files.
+func CreateStructWithOptions(tt []TableDescriptor, tableName string, opts GenerateOptions) string {
 
 	if len(tt) < 1 {
 		panic("table descriptor is empty")
 	}
 
-	withField := 0
-	withType := 0
-	temp := make([][]string, 0)
+	result, err := createStruct(tt, tableName, opts)
+	if err != nil {
+		panic(err)
+	}
 
-	for _, t := range tt {
-		row := make([]string, 0)
+	return result
+}
 
-		row = append(row, Camelize(t.Field, true))
-		row = append(row, getType(t))
-		if withJson {
-			row = append(row, Camelize(t.Field, false))
+// createStruct implements `CreateStructWithOptions`, returning template errors instead of
+// panicking.
+func createStruct(tt []TableDescriptor, tableName string, opts GenerateOptions) (string, error) {
+
+	name := opts.structName(tableName)
+	view := StructView{Name: name, TableName: tableName, Fields: make([]FieldView, 0, len(tt))}
+
+	positions := make(map[string]int, len(tt))
+	for i, t := range tt {
+		positions[t.Field] = i + 1
+		if t.OrdinalPosition > 0 {
+			positions[t.Field] = t.OrdinalPosition
+		}
+	}
+
+	for _, t := range OrderFields(tt, opts.FieldOrder) {
+		field := FieldView{
+			Name:   opts.fieldName(t.Field),
+			Type:   opts.fieldType(tableName, t),
+			Tags:   opts.structTags(t),
+			Column: t.Field,
+		}
+		if opts.WithFieldComments {
+			field.Comment = strings.Join(strings.Fields(t.Comment), " ")
 		}
-		if len(row[0]) > withField {
-			withField = len(row[0])
+		if opts.WithProtoNumbers {
+			field.ProtoNumber = positions[t.Field]
 		}
-		if len(row[1]) > withType {
-			withType = len(row[1])
+		if len(field.Name) > view.NameWidth {
+			view.NameWidth = len(field.Name)
 		}
-		temp = append(temp, row)
+		if len(field.Type) > view.TypeWidth {
+			view.TypeWidth = len(field.Type)
+		}
+		view.Fields = append(view.Fields, field)
 	}
 
-	template := fmt.Sprintf("    %%-%ds %%-%ds", withField, withType)
+	tmpl := structTemplate
+	if opts.StructTemplate != "" {
+		var err error
+		tmpl, err = template.New("struct").Funcs(structTemplateFuncs).Parse(opts.StructTemplate)
+		if err != nil {
+			return "", fmt.Errorf("failed parsing struct template: %w", err)
+		}
+	}
 
 	result := strings.Builder{}
-	result.WriteString(fmt.Sprintf("type %sData struc {\n", Camelize(tableName, true)))
+	if err := tmpl.Execute(&result, view); err != nil {
+		return "", fmt.Errorf("failed rendering struct %s: %w", name, err)
+	}
+
+	if opts.WithTableNameMethod {
+		result.WriteString(fmt.Sprintf("\n\n// TableName returns the name of the database table of %s.\n", name))
+		result.WriteString(fmt.Sprintf("func (%s) TableName() string {\n\treturn %q\n}", name, tableName))
+	}
+
+	if isView(tt) {
+		result.WriteString(fmt.Sprintf("\n\n// IsView reports that %s is read from the %s view, which cannot be written.\n", name, tableName))
+		result.WriteString(fmt.Sprintf("func (%s) IsView() bool {\n\treturn true\n}", name))
+	}
+
+	if opts.WithConstructors {
+		result.WriteString("\n\n")
+		result.WriteString(opts.constructor(tt, tableName))
+	}
 
-	for _, t := range temp {
-		result.WriteString(fmt.Sprintf(template, t[0], t[1]))
-		if len(t) == 3 {
-			result.WriteString(fmt.Sprintf("\t`json:\"%s\"`", t[2]))
+	if opts.WithStringer {
+		result.WriteString(fmt.Sprintf("\n\n// String implements fmt.Stringer for %s.\n", name))
+		result.WriteString(opts.stringMethod(tt, tableName, false, opts.Redaction))
+	}
+
+	if opts.WithColumnConstants {
+		result.WriteString("\n\n")
+		result.WriteString(opts.columnConstants(tt, tableName))
+	}
+
+	if opts.WithColumnsSlice {
+		quoted := make([]string, 0, len(tt))
+		for _, t := range tt {
+			quoted = append(quoted, fmt.Sprintf("%q", t.Field))
 		}
-		result.WriteString("\n")
+		columnsVar := opts.structBaseName(tableName) + "Columns"
+		result.WriteString(fmt.Sprintf("\n\n// %s lists the columns of the %s table in schema order.\n", columnsVar, tableName))
+		result.WriteString(fmt.Sprintf("var %s = []string{%s}", columnsVar, strings.Join(quoted, ", ")))
 	}
 
-	result.WriteString("}")
+	if opts.WithQueryHelpers {
+		result.WriteString("\n\n")
+		result.WriteString(opts.queryHelpers(tt, tableName))
+	}
 
-	return result.String()
+	if data, ok := opts.LookupTables[tableName]; ok {
+		code, err := opts.lookupVars(tt, tableName, data)
+		if err != nil {
+			return "", err
+		}
+		result.WriteString("\n\n")
+		result.WriteString(code)
+	}
+
+	return result.String(), nil
+}
+
+// DefaultStructTemplate is the `text/template` rendering struct declarations, executed
+// with a `StructView`. Custom templates set in `GenerateOptions.StructTemplate` receive
+// the same data and can use the `pad` function, which right-pads a string with spaces
+// to the given width.
+const DefaultStructTemplate = `type {{.Name}} struct {
+{{range .Fields}}{{if .Comment}}    // {{.Comment}}
+{{end}}    {{pad .Name $.NameWidth}} {{pad .Type $.TypeWidth}}{{if .Tags}}	` + "`{{.Tags}}`" + `{{end}}{{if .ProtoNumber}} // proto: {{.ProtoNumber}}{{end}}
+{{end}}}`
+
+// structTemplateFuncs are the functions available to struct templates.
+var structTemplateFuncs = template.FuncMap{
+	"pad": func(value string, width int) string {
+		return fmt.Sprintf("%-*s", width, value)
+	},
+}
+
+// structTemplate is the parsed DefaultStructTemplate.
+var structTemplate = template.Must(template.New("struct").Funcs(structTemplateFuncs).Parse(DefaultStructTemplate))
+
+// StructView is the data passed to struct templates.
+type StructView struct {
+	// Name is the name of the struct.
+	Name string
+	// TableName is the original name of the table.
+	TableName string
+	// Fields are the struct fields, in the configured order.
+	Fields []FieldView
+	// NameWidth is the length of the longest field name, used to align the fields.
+	NameWidth int
+	// TypeWidth is the length of the longest field type, used to align the fields.
+	TypeWidth int
+}
+
+// FieldView describes a struct field in a StructView.
+type FieldView struct {
+	// Name is the name of the field.
+	Name string
+	// Type is the Go type of the field.
+	Type string
+	// Tags is the content of the field tag without backquotes, e.g. `json:"id"`, or an
+	// empty string if no tag is enabled.
+	Tags string
+	// Comment is the column comment collapsed to a single line, when WithFieldComments is set.
+	Comment string
+	// ProtoNumber is the protobuf field number of the column when WithProtoNumbers is set,
+	// and zero otherwise.
+	ProtoNumber int
+	// Column is the original name of the column.
+	Column string
+}
+
+// integerTypes maps the MySQL integer types to the signed Go type able to hold them.
+// Unsigned columns use the unsigned Go type of the same width.
+var integerTypes = map[string]string{
+	"TINYINT":   "int8",
+	"SMALLINT":  "int16",
+	"MEDIUMINT": "int32",
+	"INT":       "int32",
+	"INTEGER":   "int32",
+	"BIGINT":    "int64",
 }
 
 // getType determines the Go type corresponding to a database column type.
@@ -132,66 +747,53 @@ func CreateStruct(tt []TableDescriptor, tableName string, withJson bool) string
 //   - Unsigned numeric types are prefixed with `u` to indicate unsigned integer types
 //     (e.g., `uint64` for `BIGINT UNSIGNED`).
 //   - Nullable columns are represented as pointers to their respective Go types (e.g., `*string`).
+//   - Primary key columns (`Key == "PRI"`) are always value types, even if reported as nullable.
 //   - Default Go types are provided for unknown column types, defaulting to `interface{}`.
-//   - Time-related types are mapped to `time.Time`, and binary data types are mapped to `[]byte`.
+//   - Time-related types except `YEAR` are mapped to `time.Time`, and binary data types are
+//     mapped to `[]byte`.
 //
 // Example Mappings:
 //   - `VARCHAR(255)` -> `string`
+//   - `TINYINT UNSIGNED` -> `uint8`
+//   - `SMALLINT UNSIGNED` -> `uint16`
+//   - `MEDIUMINT UNSIGNED`, `INT UNSIGNED` -> `uint32`
 //   - `BIGINT UNSIGNED` -> `uint64`
 //   - `DATETIME` -> `time.Time`
+//   - `DATETIME(6)` -> `time.Time` (fractional seconds precision is kept by `time.Time`)
+//   - `YEAR` -> `int16`
 //   - `BOOL` -> `bool`
+//   - `JSON` -> `json.RawMessage`
 func getType(t TableDescriptor) string {
 
-	cleanType := strings.ToUpper(t.Type)
-
-	// Detects UNSIGNED and removes
-	isUnsigned := strings.Contains(cleanType, "UNSIGNED")
-	cleanType = strings.ReplaceAll(cleanType, "UNSIGNED", "")
-	cleanType = strings.TrimSpace(cleanType)
-
-	//removes parantesis
-	posParentesis := strings.Index(cleanType, "(")
-	if posParentesis > 0 {
-		cleanType = cleanType[0:posParentesis]
-	}
+	isUnsigned := strings.Contains(strings.ToUpper(t.Type), "UNSIGNED")
+	cleanType := baseType(t.Type)
 
 	result := strings.Builder{}
-	if t.Null == "YES" {
+	if isNullable(t) {
 		result.WriteString("*")
 	}
 
 	switch cleanType {
 	case "VARCHAR", "TEXT", "CHAR", "ENUM", "SET", "LONGTEXT", "MEDIUMTEXT", "TINYTEXT":
 		result.WriteString("string")
-	case "BIGINT":
-		if isUnsigned {
-			result.WriteString("u") //
-		}
-		result.WriteString("int64")
-	case "INT", "MEDIUMINT":
-		if isUnsigned {
-			result.WriteString("u") //
-		}
-		result.WriteString("int32")
-	case "SMALLINT":
-		if isUnsigned {
-			result.WriteString("u") //
-		}
-		result.WriteString("int16")
-	case "TINYINT":
+	case "BIGINT", "INT", "INTEGER", "MEDIUMINT", "SMALLINT", "TINYINT":
 		if isUnsigned {
-			result.WriteString("u") //
+			result.WriteString("u")
 		}
-		result.WriteString("int8")
-	case "FLOAT", "DOUBLE", "DECIMAL":
+		result.WriteString(integerTypes[cleanType])
+	case "FLOAT", "DOUBLE", "DECIMAL", "NUMERIC":
 		result.WriteString("float64")
-	case "DATE", "DATETIME", "TIMESTAMP", "TIME", "YEAR":
+	case "YEAR":
+		result.WriteString("int16")
+	case "DATE", "DATETIME", "TIMESTAMP", "TIME":
 		result.WriteString("time.Time")
 	case "BLOB", "LONGBLOB", "MEDIUMBLOB", "TINYBLOB", "BINARY", "VARBINARY":
 		result.Reset()
 		result.WriteString("[]byte")
 	case "BIT", "BOOL", "BOOLEAN":
 		result.WriteString("bool")
+	case "JSON":
+		result.WriteString("json.RawMessage")
 	default:
 		result.Reset()
 		result.WriteString("interface{}") // If the type is not known returns generic interface
@@ -199,37 +801,1277 @@ func getType(t TableDescriptor) string {
 	return result.String()
 }
 
-// writeToFile appends a string value to a specified file.
-//
-// This function opens (or creates) a file with the specified filename, appends
-// the given string value to it, and ensures the file is properly closed afterward.
-//
-// Parameters:
+// baseType returns the upper cased column type without its length, precision or
+// `UNSIGNED` modifier, e.g. `DECIMAL` for `decimal(10,2) unsigned`.
+func baseType(dbType string) string {
+
+	cleanType := strings.ToUpper(dbType)
+
+	// Detects UNSIGNED and removes
+	cleanType = strings.ReplaceAll(cleanType, "UNSIGNED", "")
+	cleanType = strings.TrimSpace(cleanType)
+
+	//removes parantesis
+	posParentesis := strings.Index(cleanType, "(")
+	if posParentesis > 0 {
+		cleanType = strings.TrimSpace(cleanType[0:posParentesis])
+	}
+
+	return cleanType
+}
+
+// isNullable reports whether a column can hold NULL values. Primary keys cannot hold
+// NULL, so they are never treated as nullable whatever the reported `Null` value is.
+func isNullable(t TableDescriptor) bool {
+	return t.Null == "YES" && t.Key != "PRI"
+}
+
+// writeToFile writes a string value to a specified file.
+//
+// This function opens (or creates) a file with the specified filename, replaces its
+// content with the given string value, or appends to it when requested, and ensures
+// the file is properly closed afterward.
+//
+// Parameters:
 //   - value: string - The string content to write to the file.
 //   - filename: string - The name of the file to which the content will be written.
+//   - appendMode: bool - If `true`, the content is appended instead of replacing the file.
+//
+// Returns:
+//   - error: An error wrapping the cause and naming the file if it cannot be opened,
+//     written or closed.
 //
 // Behavior:
 //   - If the file does not exist, it will be created.
-//   - If the file exists, the content will be appended to the end of the file.
+//   - If the file exists, it is truncated first, so regenerating a file never duplicates
+//     its content. With `appendMode` the content is appended to the end of the file.
 //   - The file is opened with permissions set to allow reading, writing, and creation
 //     with mode `0644`.
+func writeToFile(value, filename string, appendMode bool) error {
+	flags := os.O_TRUNC | os.O_CREATE | os.O_WRONLY
+	if appendMode {
+		flags = os.O_APPEND | os.O_CREATE | os.O_WRONLY
+	}
+	f, err := os.OpenFile(filename, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed opening %s: %w", filename, err)
+	}
+	if _, err := f.WriteString(value); err != nil {
+		f.Close()
+		return fmt.Errorf("failed writing %s: %w", filename, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed closing %s: %w", filename, err)
+	}
+
+	return nil
+}
+
+// FieldOrder selects the order in which table columns are emitted as struct fields.
+type FieldOrder int
+
+const (
+	// FieldOrderSchema keeps the columns in the order reported by the database, which is
+	// their ordinal position when it is known.
+	FieldOrderSchema FieldOrder = iota
+	// FieldOrderKeysFirst emits the primary key column(s) first, then the foreign key
+	// columns, then the rest, each group keeping the schema order.
+	FieldOrderKeysFirst
+)
+
+// OrderFields returns a copy of the table descriptors sorted by the given FieldOrder.
 //
-// Panics:
-//   - The function panics if there is an error opening the file or writing to it.
+// The reordering works on whole `TableDescriptor` values, so each column keeps its
+// type, nullability and tags once it is passed to `CreateStruct`.
+//
+// Parameters:
+//   - tt: []TableDescriptor - The column descriptors of a table.
+//   - order: FieldOrder - The ordering to apply.
+//
+// Returns:
+//   - []TableDescriptor: A new slice with the columns in the requested order. The input
+//     slice is never modified.
 //
 // Notes:
-//   - Ensure appropriate error handling or pre-validation of file paths in production use.
-//   - This function is primarily designed for simple file operations; for larger or more
-//     complex I/O tasks, consider additional error handling or buffering.
-func writeToFile(value, filename string) {
-	f, err := os.OpenFile(filename,
-		os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		panic(err)
+//   - When every column has an `OrdinalPosition`, as reported by `GetSchemaDescriptors`,
+//     the schema order is the ordinal position order rather than the order of the slice,
+//     so fields always match the column order of `SELECT *`.
+//   - Foreign key columns are the ones with a `References` value. Neither `DESCRIBE`
+//     nor `GetSchemaDescriptors` reports foreign keys, so fill them with `AddForeignKeys`
+//     first; otherwise only the primary key moves. Plain indexed (`MUL`) columns keep
+//     their schema position.
+func OrderFields(tt []TableDescriptor, order FieldOrder) []TableDescriptor {
+
+	result := make([]TableDescriptor, len(tt))
+	copy(result, tt)
+
+	if hasOrdinalPositions(result) {
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].OrdinalPosition < result[j].OrdinalPosition
+		})
 	}
-	defer f.Close()
-	if _, err := f.WriteString(value); err != nil {
-		panic(err)
+
+	if order == FieldOrderKeysFirst {
+		rank := func(t TableDescriptor) int {
+			switch {
+			case t.Key == "PRI":
+				return 0
+			case t.References != "":
+				return 1
+			default:
+				return 2
+			}
+		}
+		sort.SliceStable(result, func(i, j int) bool {
+			return rank(result[i]) < rank(result[j])
+		})
+	}
+
+	return result
+}
+
+// hasOrdinalPositions reports whether every column of a table has an ordinal position.
+func hasOrdinalPositions(tt []TableDescriptor) bool {
+	for _, t := range tt {
+		if t.OrdinalPosition < 1 {
+			return false
+		}
+	}
+	return len(tt) > 0
+}
+
+// CreateFieldColumnMap generates a `FieldColumnMap` method for the struct of a table.
+//
+// The generated method returns a map from each Go field name to its SQL column name,
+// which allows building dynamic queries from field references without reading struct
+// tags through reflection. The method is emitted separately from `CreateStruct` so it is
+// only added when explicitly requested.
+//
+// Parameters:
+//   - tt: []TableDescriptor - A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the table.
+//   - tableName: string - The name of the table, used to derive the receiver struct name.
+//
+// Returns:
+//   - string: The Go source of the method.
+//
+// Example Output:
+//
+//	func (UsersData) FieldColumnMap() map[string]string {
+//		return map[string]string{
+//			"UserID": "user_id",
+//		}
+//	}
+func CreateFieldColumnMap(tt []TableDescriptor, tableName string) string {
+	return CreateFieldColumnMapWithOptions(tt, tableName, GenerateOptions{})
+}
+
+// CreateFieldColumnMapWithOptions behaves like `CreateFieldColumnMap` for the struct
+// generated with the given options.
+//
+// Parameters:
+//   - tt: []TableDescriptor - A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the table.
+//   - tableName: string - The name of the table, used to derive the receiver struct name.
+//   - opts: GenerateOptions - The options the struct was generated with, so the receiver
+//     and field names match it.
+//
+// Returns:
+//   - string: The Go source of the method.
+func CreateFieldColumnMapWithOptions(tt []TableDescriptor, tableName string, opts GenerateOptions) string {
+
+	result := strings.Builder{}
+	result.WriteString(fmt.Sprintf("func (%s) FieldColumnMap() map[string]string {\n", opts.structName(tableName)))
+	result.WriteString("\treturn map[string]string{\n")
+
+	for _, t := range OrderFields(tt, opts.FieldOrder) {
+		result.WriteString(fmt.Sprintf("\t\t%q: %q,\n", opts.fieldName(t.Field), t.Field))
+	}
+
+	result.WriteString("\t}\n}")
+
+	return result.String()
+}
+
+// sortedTableNames returns the table names of descriptors in sorted order, so generated
+// output does not depend on map iteration order.
+func sortedTableNames(descriptors map[string][]TableDescriptor) []string {
+	result := make([]string, 0, len(descriptors))
+	for k := range descriptors {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// checkStructNames returns an error if two tables generate the same struct name, as
+// happens when TrimTablePrefix or Singularize reduce them to the same base name.
+func checkStructNames(descriptors map[string][]TableDescriptor, opts GenerateOptions) error {
+	tables := make(map[string]string, len(descriptors))
+	for _, tableName := range sortedTableNames(descriptors) {
+		name := opts.structName(tableName)
+		if other, ok := tables[name]; ok {
+			return fmt.Errorf("tables %s and %s both generate struct %s", other, tableName, name)
+		}
+		tables[name] = tableName
+	}
+	return nil
+}
+
+// isView reports whether the descriptors belong to a view, as set by view-aware introspection.
+func isView(tt []TableDescriptor) bool {
+	for _, t := range tt {
+		if t.View {
+			return true
+		}
+	}
+	return false
+}
+
+// PrimaryKeys returns the names of the primary key columns of a table.
+//
+// Tables with a composite primary key report several columns with `Key == "PRI"`; this
+// function returns all of them, so generated statements can match every key column.
+//
+// Parameters:
+//   - tt: []TableDescriptor - A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the table.
+//
+// Returns:
+//   - []string: The primary key column names in schema order. The slice is empty if the
+//     table has no primary key.
+//
+// Example Usage:
+//   - For `PRIMARY KEY (order_id, line)`, PrimaryKeys(tt) -> ["order_id", "line"]
+func PrimaryKeys(tt []TableDescriptor) []string {
+
+	result := make([]string, 0)
+	for _, t := range tt {
+		if t.Key == "PRI" {
+			result = append(result, t.Field)
+		}
+	}
+
+	return result
+}
+
+// IsAutoIncrement reports whether the database generates the values of a column.
+//
+// MySQL reports `auto_increment` in `Extra`, and the Postgres dialect reports identity and
+// serial columns the same way. Generated INSERT statements and constructors leave these
+// columns out, and GORM tags mark them with `autoIncrement`.
+//
+// Parameters:
+//   - t: TableDescriptor - The descriptor of the column.
+//
+// Returns:
+//   - bool: `true` if the column is auto increment.
+func IsAutoIncrement(t TableDescriptor) bool {
+	return strings.Contains(strings.ToLower(t.Extra), "auto_increment")
+}
+
+// CreateBatchGetByIDs generates a function that loads many rows of a table by primary key.
+//
+// The generated function, named `Get<Struct>ByIDs`, builds a `WHERE <pk> IN (?, ...)`
+// query and scans every returned row into the struct produced by `CreateStruct`, which
+// avoids issuing one query per id. Large id slices are split into batches so the number
+// of placeholders in a single statement stays bounded.
+//
+// Parameters:
+//   - tt: []TableDescriptor - A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the table.
+//   - tableName: string - The name of the table to query.
+//   - batchSize: int - The maximum number of ids sent in a single query. Values lower
+//     than 1 default to 1000.
+//
+// Returns:
+//   - string: The Go source of the function.
+//   - error: An error if the table does not have exactly one primary key column.
+//
+// Notes:
+//   - The generated code uses the `context`, `database/sql` and `strings` packages, which
+//     must be imported by the file it is written to.
+//   - An empty id slice returns an empty result without querying the database.
+//   - Rows are returned in the order the database yields them, not in the order of `ids`.
+func CreateBatchGetByIDs(tt []TableDescriptor, tableName string, batchSize int) (string, error) {
+	return CreateBatchGetByIDsWithOptions(tt, tableName, batchSize, GenerateOptions{})
+}
+
+// CreateBatchGetByIDsWithOptions behaves like `CreateBatchGetByIDs` for the struct generated
+// with the given options.
+//
+// Parameters:
+//   - tt: []TableDescriptor - A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the table.
+//   - tableName: string - The name of the table to query.
+//   - batchSize: int - The maximum number of ids sent in a single query. Values lower
+//     than 1 default to 1000.
+//   - opts: GenerateOptions - The options the struct was generated with, so the struct,
+//     field and key types match it.
+//
+// Returns:
+//   - string: The Go source of the function.
+//   - error: An error if the table does not have exactly one primary key column.
+func CreateBatchGetByIDsWithOptions(tt []TableDescriptor, tableName string, batchSize int, opts GenerateOptions) (string, error) {
+
+	if batchSize < 1 {
+		batchSize = 1000
+	}
+
+	keys := PrimaryKeys(tt)
+	switch {
+	case len(keys) == 0:
+		return "", fmt.Errorf("table %s has no primary key", tableName)
+	case len(keys) > 1:
+		return "", fmt.Errorf("table %s has a composite primary key", tableName)
+	}
+
+	var pk TableDescriptor
+	columns := make([]string, 0, len(tt))
+	destinations := make([]string, 0, len(tt))
+	for _, t := range OrderFields(tt, opts.FieldOrder) {
+		if t.Field == keys[0] {
+			pk = t
+		}
+		columns = append(columns, QuoteIdentifier(t.Field))
+		destinations = append(destinations, "&r."+opts.fieldName(t.Field))
+	}
+
+	name := opts.structName(tableName)
+	idType := strings.TrimPrefix(opts.fieldType(tableName, pk), "*")
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s IN (?", strings.Join(columns, ", "), QuoteIdentifier(tableName), QuoteIdentifier(pk.Field))
+
+	result := strings.Builder{}
+	result.WriteString(fmt.Sprintf("// Get%sByIDs loads the %s rows whose %s is in ids, querying at most %d ids at a time.\n", name, tableName, pk.Field, batchSize))
+	result.WriteString(fmt.Sprintf("func Get%sByIDs(ctx context.Context, db *sql.DB, ids []%s) ([]%s, error) {\n", name, idType, name))
+	result.WriteString(fmt.Sprintf("\tresult := make([]%s, 0, len(ids))\n", name))
+	result.WriteString(fmt.Sprintf("\tfor start := 0; start < len(ids); start += %d {\n", batchSize))
+	result.WriteString(fmt.Sprintf("\t\tend := start + %d\n", batchSize))
+	result.WriteString("\t\tif end > len(ids) {\n\t\t\tend = len(ids)\n\t\t}\n")
+	result.WriteString("\t\targs := make([]interface{}, 0, end-start)\n")
+	result.WriteString("\t\tfor _, id := range ids[start:end] {\n\t\t\targs = append(args, id)\n\t\t}\n")
+	result.WriteString(fmt.Sprintf("\t\tquery := %q + strings.Repeat(\", ?\", len(args)-1) + \")\"\n", query))
+	result.WriteString("\t\trows, err := db.QueryContext(ctx, query, args...)\n")
+	result.WriteString("\t\tif err != nil {\n\t\t\treturn nil, err\n\t\t}\n")
+	result.WriteString("\t\tfor rows.Next() {\n")
+	result.WriteString(fmt.Sprintf("\t\t\tr := %s{}\n", name))
+	result.WriteString(fmt.Sprintf("\t\t\tif err := rows.Scan(%s); err != nil {\n", strings.Join(destinations, ", ")))
+	result.WriteString("\t\t\t\trows.Close()\n\t\t\t\treturn nil, err\n\t\t\t}\n")
+	result.WriteString("\t\t\tresult = append(result, r)\n\t\t}\n")
+	result.WriteString("\t\terr = rows.Err()\n\t\trows.Close()\n")
+	result.WriteString("\t\tif err != nil {\n\t\t\treturn nil, err\n\t\t}\n")
+	result.WriteString("\t}\n\treturn result, nil\n}")
+
+	return result.String(), nil
+}
+
+// CreateColumnCountConst generates a constant recording the number of columns of a table.
+//
+// The constant, named `<Table>ColumnCount`, captures the shape of the table at generation
+// time. Passing these constants to `VerifyColumnCounts` detects columns added or removed
+// since the code was last regenerated.
+//
+// Parameters:
+//   - tt: []TableDescriptor - A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the table.
+//   - tableName: string - The name of the table, used to derive the constant name.
+//
+// Returns:
+//   - string: The Go source of the constant, e.g. `const UsersColumnCount = 7`.
+func CreateColumnCountConst(tt []TableDescriptor, tableName string) string {
+	return CreateColumnCountConstWithOptions(tt, tableName, GenerateOptions{})
+}
+
+// CreateColumnCountConstWithOptions behaves like `CreateColumnCountConst`, naming the
+// constant after the struct generated with the given options, without its suffix.
+//
+// Parameters:
+//   - tt: []TableDescriptor - A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the table.
+//   - tableName: string - The name of the table, used to derive the constant name.
+//   - opts: GenerateOptions - The options the struct was generated with.
+//
+// Returns:
+//   - string: The Go source of the constant, e.g. `const UserColumnCount = 7` for the
+//     `users` table with `Singularize` set.
+func CreateColumnCountConstWithOptions(tt []TableDescriptor, tableName string, opts GenerateOptions) string {
+	return fmt.Sprintf("const %sColumnCount = %d", opts.structBaseName(tableName), len(tt))
+}
+
+// ProjectColumns filters and orders table descriptors to match an explicit column list.
+//
+// This function is meant for tables that are always read with a canonical
+// `SELECT col_a, col_b, ...` projection. Passing the result to `CreateStruct` yields a
+// struct whose fields follow the projection, so rows can be scanned into it in order.
+//
+// Parameters:
+//   - tt: []TableDescriptor - The column descriptors of a table.
+//   - columns: []string - The column names to keep, in the order they should appear.
+//
+// Returns:
+//   - []TableDescriptor: The descriptors of the listed columns, in the listed order.
+//   - error: An error naming the first listed column that does not exist in the table.
+func ProjectColumns(tt []TableDescriptor, columns []string) ([]TableDescriptor, error) {
+
+	byName := make(map[string]TableDescriptor, len(tt))
+	for _, t := range tt {
+		byName[t.Field] = t
+	}
+
+	result := make([]TableDescriptor, 0, len(columns))
+	for _, c := range columns {
+		t, ok := byName[c]
+		if !ok {
+			return nil, fmt.Errorf("column %s does not exist", c)
+		}
+		result = append(result, t)
+	}
+
+	return result, nil
+}
+
+// CreateStringMethod generates a `String` method for the struct of a table.
+//
+// The generated method implements `fmt.Stringer` and renders the struct as
+// `UsersData{ID=1 Name=<nil> Data=0a0b...(40 bytes)}`. Pointer fields are dereferenced
+// only when non-nil, and `[]byte` fields are printed in hex, truncated to their first
+// 32 bytes, so the method is safe to use for logging and test output.
+//
+// Parameters:
+//   - tt: []TableDescriptor - A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the table.
+//   - tableName: string - The name of the table, used to derive the receiver struct name.
+//   - omitNil: bool - If `true`, nil pointer fields are left out of the output instead of
+//     being printed as `<nil>`.
+//
+// Returns:
+//   - string: The Go source of the method.
+//
+// Notes:
+//   - The generated code uses the `fmt` and `strings` packages, which must be imported by
+//     the file it is written to.
+//   - Use `CreateRedactedStringMethod` to mask sensitive columns.
+func CreateStringMethod(tt []TableDescriptor, tableName string, omitNil bool) string {
+	return CreateRedactedStringMethod(tt, tableName, omitNil, nil)
+}
+
+// DefaultSensitiveColumns matches column names that commonly hold secrets or personal data.
+var DefaultSensitiveColumns = regexp.MustCompile(`(?i)(password|passwd|secret|token|ssn|api_?key)`)
+
+// RedactionPolicy decides which columns are masked in generated logging methods.
+type RedactionPolicy struct {
+	// Matcher selects the sensitive columns by name. A nil Matcher uses DefaultSensitiveColumns.
+	Matcher *regexp.Regexp
+	// Mask replaces the value of sensitive columns. An empty Mask defaults to "***".
+	Mask string
+}
+
+// IsSensitive reports whether the column must be masked under the policy.
+func (p *RedactionPolicy) IsSensitive(column string) bool {
+	if p == nil {
+		return false
+	}
+	if p.Matcher == nil {
+		return DefaultSensitiveColumns.MatchString(column)
+	}
+	return p.Matcher.MatchString(column)
+}
+
+// mask returns the replacement text for sensitive values.
+func (p *RedactionPolicy) mask() string {
+	if p == nil || p.Mask == "" {
+		return "***"
+	}
+	return p.Mask
+}
+
+// CreateRedactedStringMethod generates a `String` method that masks sensitive columns.
+//
+// The generated method behaves like the one produced by `CreateStringMethod`, except that
+// columns selected by the policy are always printed with the policy mask, so secrets and
+// personal data never reach the logs through the struct.
+//
+// Parameters:
+//   - tt: []TableDescriptor - A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the table.
+//   - tableName: string - The name of the table, used to derive the receiver struct name.
+//   - omitNil: bool - If `true`, nil pointer fields are left out of the output instead of
+//     being printed as `<nil>`.
+//   - policy: *RedactionPolicy - The policy selecting the columns to mask. A nil policy
+//     masks nothing.
+//
+// Returns:
+//   - string: The Go source of the method.
+func CreateRedactedStringMethod(tt []TableDescriptor, tableName string, omitNil bool, policy *RedactionPolicy) string {
+	return GenerateOptions{}.stringMethod(tt, tableName, omitNil, policy)
+}
+
+// stringMethod implements `CreateRedactedStringMethod` for the struct generated with the options.
+func (o GenerateOptions) stringMethod(tt []TableDescriptor, tableName string, omitNil bool, policy *RedactionPolicy) string {
+
+	name := o.structName(tableName)
+
+	result := strings.Builder{}
+	result.WriteString(fmt.Sprintf("func (x %s) String() string {\n", name))
+	result.WriteString(fmt.Sprintf("\tparts := make([]string, 0, %d)\n", len(tt)))
+
+	for _, t := range OrderFields(tt, o.FieldOrder) {
+		field := o.fieldName(t.Field)
+		fieldType := o.fieldType(tableName, t)
+
+		switch {
+		case policy.IsSensitive(t.Field):
+			result.WriteString(fmt.Sprintf("\tparts = append(parts, %q)\n", field+"="+policy.mask()))
+		case fieldType == "[]byte", o.bytesType(t) != "":
+			result.WriteString(fmt.Sprintf("\tif len(x.%s) > 32 {\n", field))
+			result.WriteString(fmt.Sprintf("\t\tparts = append(parts, fmt.Sprintf(\"%s=%%x...(%%d bytes)\", x.%s[:32], len(x.%s)))\n", field, field, field))
+			result.WriteString("\t} else {\n")
+			result.WriteString(fmt.Sprintf("\t\tparts = append(parts, fmt.Sprintf(\"%s=%%x\", x.%s))\n", field, field))
+			result.WriteString("\t}\n")
+		case strings.HasPrefix(fieldType, "*"):
+			result.WriteString(fmt.Sprintf("\tif x.%s != nil {\n", field))
+			result.WriteString(fmt.Sprintf("\t\tparts = append(parts, fmt.Sprintf(\"%s=%%v\", *x.%s))\n", field, field))
+			if omitNil {
+				result.WriteString("\t}\n")
+			} else {
+				result.WriteString("\t} else {\n")
+				result.WriteString(fmt.Sprintf("\t\tparts = append(parts, \"%s=<nil>\")\n", field))
+				result.WriteString("\t}\n")
+			}
+		default:
+			result.WriteString(fmt.Sprintf("\tparts = append(parts, fmt.Sprintf(\"%s=%%v\", x.%s))\n", field, field))
+		}
+	}
+
+	result.WriteString(fmt.Sprintf("\treturn \"%s{\" + strings.Join(parts, \" \") + \"}\"\n}", name))
+
+	return result.String()
+}
+
+// CreateSetBitmaskType generates a bitmask type for the members of a SET column.
+//
+// The generated type is a `uint64` with one constant per SET member, in definition
+// order, plus `Scan` and `Value` methods that convert between the bitmask and the
+// comma-joined string MySQL uses for SET values. The empty set maps to zero.
+//
+// Parameters:
+//   - t: TableDescriptor - The descriptor of the SET column, e.g. with Type `set('a','b')`.
+//   - typeName: string - The name of the generated type, also used to prefix its constants.
+//
+// Returns:
+//   - string: The Go source of the type, its constants and its methods.
+//   - error: An error if the column is not a SET or has no members.
+//
+// Notes:
+//   - The generated code uses the `database/sql/driver`, `fmt` and `strings` packages,
+//     which must be imported by the file it is written to.
+//   - Members that do not form a valid identifier get a constant named after their
+//     position, e.g. `<typeName>Member2`.
+func CreateSetBitmaskType(t TableDescriptor, typeName string) (string, error) {
+
+	if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(t.Type)), "SET") {
+		return "", fmt.Errorf("column %s is not a SET", t.Field)
+	}
+
+	members := parseTypeValues(t.Type)
+	if len(members) == 0 {
+		return "", fmt.Errorf("column %s has no SET members", t.Field)
+	}
+
+	namesVar := Camelize(typeName, false) + "Names"
+
+	result := strings.Builder{}
+	result.WriteString(fmt.Sprintf("// %s is a bitmask of the members of the %s SET column.\n", typeName, t.Field))
+	result.WriteString(fmt.Sprintf("type %s uint64\n\n", typeName))
+
+	result.WriteString("const (\n")
+	for i, m := range members {
+		constName := fmt.Sprintf("%sMember%d", typeName, i+1)
+		if isIdentifier(m) {
+			constName = typeName + Camelize(m, true)
+		}
+		if i == 0 {
+			result.WriteString(fmt.Sprintf("\t%s %s = 1 << iota\n", constName, typeName))
+		} else {
+			result.WriteString(fmt.Sprintf("\t%s\n", constName))
+		}
+	}
+	result.WriteString(")\n\n")
+
+	quoted := make([]string, 0, len(members))
+	for _, m := range members {
+		quoted = append(quoted, fmt.Sprintf("%q", m))
+	}
+	result.WriteString(fmt.Sprintf("var %s = []string{%s}\n\n", namesVar, strings.Join(quoted, ", ")))
+
+	result.WriteString(fmt.Sprintf("// Scan implements sql.Scanner for %s.\n", typeName))
+	result.WriteString(fmt.Sprintf("func (s *%s) Scan(src interface{}) error {\n", typeName))
+	result.WriteString("\tvalue := \"\"\n")
+	result.WriteString("\tswitch v := src.(type) {\n")
+	result.WriteString("\tcase nil:\n")
+	result.WriteString("\tcase []byte:\n\t\tvalue = string(v)\n")
+	result.WriteString("\tcase string:\n\t\tvalue = v\n")
+	result.WriteString("\tdefault:\n")
+	result.WriteString(fmt.Sprintf("\t\treturn fmt.Errorf(\"cannot scan %%T into %s\", src)\n", typeName))
+	result.WriteString("\t}\n")
+	result.WriteString("\t*s = 0\n")
+	result.WriteString("\tif value == \"\" {\n\t\treturn nil\n\t}\n")
+	result.WriteString("\tfor _, member := range strings.Split(value, \",\") {\n")
+	result.WriteString("\t\tfound := false\n")
+	result.WriteString(fmt.Sprintf("\t\tfor i, name := range %s {\n", namesVar))
+	result.WriteString("\t\t\tif name == member {\n")
+	result.WriteString(fmt.Sprintf("\t\t\t\t*s |= %s(1) << i\n", typeName))
+	result.WriteString("\t\t\t\tfound = true\n\t\t\t\tbreak\n\t\t\t}\n\t\t}\n")
+	result.WriteString("\t\tif !found {\n")
+	result.WriteString(fmt.Sprintf("\t\t\treturn fmt.Errorf(\"unknown %s member %%q\", member)\n", typeName))
+	result.WriteString("\t\t}\n\t}\n")
+	result.WriteString("\treturn nil\n}\n\n")
+
+	result.WriteString(fmt.Sprintf("// Value implements driver.Valuer for %s.\n", typeName))
+	result.WriteString(fmt.Sprintf("func (s %s) Value() (driver.Value, error) {\n", typeName))
+	result.WriteString(fmt.Sprintf("\tmembers := make([]string, 0, len(%s))\n", namesVar))
+	result.WriteString(fmt.Sprintf("\tfor i, name := range %s {\n", namesVar))
+	result.WriteString(fmt.Sprintf("\t\tif s&(%s(1)<<i) != 0 {\n", typeName))
+	result.WriteString("\t\t\tmembers = append(members, name)\n\t\t}\n\t}\n")
+	result.WriteString("\treturn strings.Join(members, \",\"), nil\n}")
+
+	return result.String(), nil
+}
+
+// CreateEnumType generates a named string type for the allowed values of an ENUM column.
+//
+// The generated type has one constant per value, in definition order, named after the
+// type and the camelized value, e.g. `UserStatusActive` for the value `active`. It
+// implements `fmt.Stringer`, and a `Parse<typeName>` function converts a string into the
+// type, returning an error for values the column does not allow.
+//
+// Parameters:
+//   - t: TableDescriptor - The descriptor of the ENUM column, e.g. with Type `enum('a','b')`.
+//   - typeName: string - The name of the generated type, also used to prefix its constants.
+//
+// Returns:
+//   - string: The Go source of the type, its constants, its `String` method and its
+//     `Parse<typeName>` function.
+//   - error: An error if the column is not an ENUM or has no values.
+//
+// Notes:
+//   - The generated code uses the `fmt` package, which must be imported by the file it is
+//     written to.
+//   - Values are unescaped from the column type, so quotes escaped by doubling or by a
+//     backslash are kept once in the constant value.
+//   - Values that do not produce a usable name, e.g. the empty string, or whose name is
+//     already taken get a constant named after their position, e.g. `<typeName>Value2`.
+func CreateEnumType(t TableDescriptor, typeName string) (string, error) {
+
+	if !isEnum(t) {
+		return "", fmt.Errorf("column %s is not an ENUM", t.Field)
+	}
+
+	values := parseTypeValues(t.Type)
+	if len(values) == 0 {
+		return "", fmt.Errorf("column %s has no ENUM values", t.Field)
+	}
+
+	names := make([]string, 0, len(values))
+	used := make(map[string]bool)
+	width := 0
+	for i, v := range values {
+		name := typeName + Camelize(toWords(v), true)
+		if name == typeName || used[name] {
+			name = fmt.Sprintf("%sValue%d", typeName, i+1)
+		}
+		used[name] = true
+		names = append(names, name)
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+
+	result := strings.Builder{}
+	result.WriteString(fmt.Sprintf("// %s enumerates the values of the %s ENUM column.\n", typeName, t.Field))
+	result.WriteString(fmt.Sprintf("type %s string\n\n", typeName))
+
+	result.WriteString("const (\n")
+	for i, v := range values {
+		result.WriteString(fmt.Sprintf("\t%-*s %s = %q\n", width, names[i], typeName, v))
+	}
+	result.WriteString(")\n\n")
+
+	result.WriteString(fmt.Sprintf("// String implements fmt.Stringer for %s, returning the raw value.\n", typeName))
+	result.WriteString(fmt.Sprintf("func (e %s) String() string {\n\treturn string(e)\n}\n\n", typeName))
+
+	result.WriteString(fmt.Sprintf("// Parse%s returns the %s holding s, or an error if s is not one of its values.\n", typeName, typeName))
+	result.WriteString(fmt.Sprintf("func Parse%s(s string) (%s, error) {\n", typeName, typeName))
+	result.WriteString(fmt.Sprintf("\tswitch e := %s(s); e {\n", typeName))
+	result.WriteString(fmt.Sprintf("\tcase %s:\n\t\treturn e, nil\n\t}\n", strings.Join(names, ", ")))
+	result.WriteString(fmt.Sprintf("\treturn \"\", fmt.Errorf(\"unknown %s value %%q\", s)\n}", typeName))
+
+	return result.String(), nil
+}
+
+// isEnum reports whether a column is a MySQL ENUM.
+func isEnum(t TableDescriptor) bool {
+	return baseType(t.Type) == "ENUM"
+}
+
+// CreateSetType generates a named `[]string` type for the members of a SET column.
+//
+// The generated type implements `sql.Scanner` and `driver.Valuer`, splitting and joining
+// the comma separated string MySQL uses for SET values. The empty set scans into an empty,
+// non-nil slice. The allowed members are listed in the doc comment of the type.
+//
+// Parameters:
+//   - t: TableDescriptor - The descriptor of the SET column, e.g. with Type `set('a','b')`.
+//   - typeName: string - The name of the generated type.
+//
+// Returns:
+//   - string: The Go source of the type and its methods.
+//   - error: An error if the column is not a SET.
+//
+// Notes:
+//   - The generated code uses the `database/sql/driver`, `fmt` and `strings` packages,
+//     which must be imported by the file it is written to.
+//   - Use `CreateSetBitmaskType` for a compact representation checked against the members.
+func CreateSetType(t TableDescriptor, typeName string) (string, error) {
+
+	if !isSet(t) {
+		return "", fmt.Errorf("column %s is not a SET", t.Field)
+	}
+
+	quoted := make([]string, 0)
+	for _, m := range parseTypeValues(t.Type) {
+		quoted = append(quoted, fmt.Sprintf("%q", m))
+	}
+
+	result := strings.Builder{}
+	result.WriteString(fmt.Sprintf("// %s holds the members of the %s SET column.\n", typeName, t.Field))
+	if len(quoted) > 0 {
+		result.WriteString(fmt.Sprintf("// Allowed members: %s.\n", strings.Join(quoted, ", ")))
+	}
+	result.WriteString(fmt.Sprintf("type %s []string\n\n", typeName))
+
+	result.WriteString(fmt.Sprintf("// Scan implements sql.Scanner for %s.\n", typeName))
+	result.WriteString(fmt.Sprintf("func (s *%s) Scan(src interface{}) error {\n", typeName))
+	result.WriteString("\tvalue := \"\"\n")
+	result.WriteString("\tswitch v := src.(type) {\n")
+	result.WriteString("\tcase nil:\n\t\t*s = nil\n\t\treturn nil\n")
+	result.WriteString("\tcase []byte:\n\t\tvalue = string(v)\n")
+	result.WriteString("\tcase string:\n\t\tvalue = v\n")
+	result.WriteString("\tdefault:\n")
+	result.WriteString(fmt.Sprintf("\t\treturn fmt.Errorf(\"cannot scan %%T into %s\", src)\n", typeName))
+	result.WriteString("\t}\n")
+	result.WriteString(fmt.Sprintf("\tif value == \"\" {\n\t\t*s = %s{}\n\t\treturn nil\n\t}\n", typeName))
+	result.WriteString("\t*s = strings.Split(value, \",\")\n")
+	result.WriteString("\treturn nil\n}\n\n")
+
+	result.WriteString(fmt.Sprintf("// Value implements driver.Valuer for %s.\n", typeName))
+	result.WriteString(fmt.Sprintf("func (s %s) Value() (driver.Value, error) {\n", typeName))
+	result.WriteString("\treturn strings.Join(s, \",\"), nil\n}")
+
+	return result.String(), nil
+}
+
+// CreateBytesType generates a named `[]byte` type for binary columns.
+//
+// A named type tells apart binary columns holding different things, like hashes, images
+// or UUIDs, which would otherwise all be `[]byte`. The generated type implements
+// `sql.Scanner`, copying the scanned bytes so they outlive the row, and `driver.Valuer`,
+// and has a `Hex` method and a `Parse<typeName>Hex` function converting to and from
+// hexadecimal strings.
+//
+// Parameters:
+//   - typeName: string - The name of the generated type, e.g. "HashBytes".
+//
+// Returns:
+//   - string: The Go source of the type, its methods and its parse function.
+//
+// Notes:
+//   - The generated code uses the `database/sql/driver`, `encoding/hex` and `fmt`
+//     packages, which must be imported by the file it is written to.
+//   - The type is used for the binary columns selected by `GenerateOptions.BytesTypes`.
+func CreateBytesType(typeName string) string {
+
+	result := strings.Builder{}
+	result.WriteString(fmt.Sprintf("// %s holds the bytes of a binary column.\n", typeName))
+	result.WriteString(fmt.Sprintf("type %s []byte\n\n", typeName))
+
+	result.WriteString(fmt.Sprintf("// Parse%sHex decodes a hexadecimal string into a %s.\n", typeName, typeName))
+	result.WriteString(fmt.Sprintf("func Parse%sHex(s string) (%s, error) {\n", typeName, typeName))
+	result.WriteString("\tb, err := hex.DecodeString(s)\n")
+	result.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	result.WriteString(fmt.Sprintf("\treturn %s(b), nil\n}\n\n", typeName))
+
+	result.WriteString("// Hex returns the hexadecimal encoding of the bytes.\n")
+	result.WriteString(fmt.Sprintf("func (b %s) Hex() string {\n", typeName))
+	result.WriteString("\treturn hex.EncodeToString(b)\n}\n\n")
+
+	result.WriteString(fmt.Sprintf("// Scan implements sql.Scanner for %s.\n", typeName))
+	result.WriteString(fmt.Sprintf("func (b *%s) Scan(src interface{}) error {\n", typeName))
+	result.WriteString("\tswitch v := src.(type) {\n")
+	result.WriteString("\tcase nil:\n\t\t*b = nil\n")
+	result.WriteString(fmt.Sprintf("\tcase []byte:\n\t\t*b = append(%s{}, v...)\n", typeName))
+	result.WriteString(fmt.Sprintf("\tcase string:\n\t\t*b = %s(v)\n", typeName))
+	result.WriteString("\tdefault:\n")
+	result.WriteString(fmt.Sprintf("\t\treturn fmt.Errorf(\"cannot scan %%T into %s\", src)\n", typeName))
+	result.WriteString("\t}\n\treturn nil\n}\n\n")
+
+	result.WriteString(fmt.Sprintf("// Value implements driver.Valuer for %s.\n", typeName))
+	result.WriteString(fmt.Sprintf("func (b %s) Value() (driver.Value, error) {\n", typeName))
+	result.WriteString("\tif b == nil {\n\t\treturn nil, nil\n\t}\n")
+	result.WriteString("\treturn []byte(b), nil\n}")
+
+	return result.String()
+}
+
+// nullTimeType is the name of the type generated by `CreateNullTimeType`.
+const nullTimeType = "NullTime"
+
+// CreateNullTimeType generates a `NullTime` type for nullable time columns.
+//
+// The generated type holds a `time.Time` and a validity flag, like `sql.NullTime`, and
+// implements `sql.Scanner`, `driver.Valuer`, `json.Marshaler` and `json.Unmarshaler`. NULL
+// is marshalled as JSON null and any other value as an RFC 3339 string with fractional
+// seconds, so the zero time is never confused with a missing value.
+//
+// Returns:
+//   - string: The Go source of the type and its methods.
+//
+// Notes:
+//   - The generated code uses the `database/sql/driver`, `fmt` and `time` packages, which
+//     must be imported by the file it is written to.
+//   - The type is used for nullable time columns when `GenerateOptions.WithNullTime` is set.
+func CreateNullTimeType() string {
+
+	result := strings.Builder{}
+	result.WriteString("// NullTime is a time.Time that may be NULL, marshalled to JSON as an RFC 3339 string or null.\n")
+	result.WriteString("type NullTime struct {\n\tTime  time.Time\n\tValid bool\n}\n\n")
+
+	result.WriteString("// Scan implements sql.Scanner for NullTime.\n")
+	result.WriteString("func (t *NullTime) Scan(src interface{}) error {\n")
+	result.WriteString("\tswitch v := src.(type) {\n")
+	result.WriteString("\tcase nil:\n\t\t*t = NullTime{}\n")
+	result.WriteString("\tcase time.Time:\n\t\t*t = NullTime{Time: v, Valid: true}\n")
+	result.WriteString("\tdefault:\n\t\treturn fmt.Errorf(\"cannot scan %T into NullTime\", src)\n")
+	result.WriteString("\t}\n\treturn nil\n}\n\n")
+
+	result.WriteString("// Value implements driver.Valuer for NullTime.\n")
+	result.WriteString("func (t NullTime) Value() (driver.Value, error) {\n")
+	result.WriteString("\tif !t.Valid {\n\t\treturn nil, nil\n\t}\n")
+	result.WriteString("\treturn t.Time, nil\n}\n\n")
+
+	result.WriteString("// MarshalJSON implements json.Marshaler for NullTime.\n")
+	result.WriteString("func (t NullTime) MarshalJSON() ([]byte, error) {\n")
+	result.WriteString("\tif !t.Valid {\n\t\treturn []byte(\"null\"), nil\n\t}\n")
+	result.WriteString("\treturn []byte(`\"` + t.Time.Format(time.RFC3339Nano) + `\"`), nil\n}\n\n")
+
+	result.WriteString("// UnmarshalJSON implements json.Unmarshaler for NullTime.\n")
+	result.WriteString("func (t *NullTime) UnmarshalJSON(data []byte) error {\n")
+	result.WriteString("\tif string(data) == \"null\" {\n\t\t*t = NullTime{}\n\t\treturn nil\n\t}\n")
+	result.WriteString("\tvalue, err := time.Parse(`\"`+time.RFC3339Nano+`\"`, string(data))\n")
+	result.WriteString("\tif err != nil {\n\t\treturn err\n\t}\n")
+	result.WriteString("\t*t = NullTime{Time: value, Valid: true}\n\treturn nil\n}")
+
+	return result.String()
+}
+
+// isSet reports whether a column is a MySQL SET.
+func isSet(t TableDescriptor) bool {
+	return baseType(t.Type) == "SET"
+}
+
+// parseTypeValues extracts the quoted values of an ENUM or SET column type.
+//
+// A type like `set('a','b')` yields ["a", "b"]. Quotes escaped inside a value,
+// either doubled or with a backslash, are unescaped.
+func parseTypeValues(dbType string) []string {
+
+	start := strings.Index(dbType, "(")
+	end := strings.LastIndex(dbType, ")")
+	if start < 0 || end < start {
+		return nil
+	}
+
+	result := make([]string, 0)
+	inner := dbType[start+1 : end]
+	current := strings.Builder{}
+	inQuotes := false
+
+	for i := 0; i < len(inner); i++ {
+		c := inner[i]
+		switch {
+		case !inQuotes && c == '\'':
+			inQuotes = true
+			current.Reset()
+		case inQuotes && c == '\\' && i+1 < len(inner):
+			i++
+			current.WriteByte(inner[i])
+		case inQuotes && c == '\'' && i+1 < len(inner) && inner[i+1] == '\'':
+			i++
+			current.WriteByte('\'')
+		case inQuotes && c == '\'':
+			inQuotes = false
+			result = append(result, current.String())
+		case inQuotes:
+			current.WriteByte(c)
+		}
+	}
+
+	return result
+}
+
+// CreateMapper generates a method converting the struct of one schema version into another.
+//
+// This function compares the column descriptors of two versions of the same table and
+// generates a `To<NewStruct>` method on the old struct that copies every column present
+// in both versions with the same Go type. Columns that cannot be mapped automatically are
+// listed in a report so they can be handled by hand.
+//
+// Parameters:
+//   - oldTT: []TableDescriptor - The column descriptors of the old table version.
+//   - oldTableName: string - The table name used to derive the old struct name.
+//   - newTT: []TableDescriptor - The column descriptors of the new table version.
+//   - newTableName: string - The table name used to derive the new struct name.
+//
+// Returns:
+//   - string: The Go source of the mapping method.
+//   - []string: One line per column that was not mapped, describing whether it was
+//     removed, added or changed type.
+//
+// Example Output:
+//
+//	func (old OldUsersData) ToUsersData() UsersData {
+//		return UsersData{
+//			ID: old.ID,
+//		}
+//	}
+func CreateMapper(oldTT []TableDescriptor, oldTableName string, newTT []TableDescriptor, newTableName string) (string, []string) {
+	return CreateMapperWithOptions(oldTT, oldTableName, newTT, newTableName, GenerateOptions{})
+}
+
+// CreateMapperWithOptions behaves like `CreateMapper` for structs generated with the given
+// options.
+//
+// Parameters:
+//   - oldTT: []TableDescriptor - The column descriptors of the old table version.
+//   - oldTableName: string - The table name used to derive the old struct name.
+//   - newTT: []TableDescriptor - The column descriptors of the new table version.
+//   - newTableName: string - The table name used to derive the new struct name.
+//   - opts: GenerateOptions - The options both structs were generated with, so struct
+//     names, field names and field types match them.
+//
+// Returns:
+//   - string: The Go source of the mapping method.
+//   - []string: One line per column that was not mapped, describing whether it was
+//     removed, added or changed type.
+//
+// Notes:
+//   - With `WithEnumTypes` or `WithSetTypes`, the named types of both versions are
+//     different types, so their columns are reported as changed.
+func CreateMapperWithOptions(oldTT []TableDescriptor, oldTableName string, newTT []TableDescriptor, newTableName string, opts GenerateOptions) (string, []string) {
+
+	oldTypes := make(map[string]string, len(oldTT))
+	for _, t := range oldTT {
+		oldTypes[t.Field] = opts.fieldType(oldTableName, t)
+	}
+
+	newFields := make(map[string]bool, len(newTT))
+	report := make([]string, 0)
+	oldName := opts.structName(oldTableName)
+	newName := opts.structName(newTableName)
+
+	result := strings.Builder{}
+	result.WriteString(fmt.Sprintf("func (old %s) To%s() %s {\n", oldName, newName, newName))
+	result.WriteString(fmt.Sprintf("\treturn %s{\n", newName))
+
+	for _, t := range OrderFields(newTT, opts.FieldOrder) {
+		newFields[t.Field] = true
+		oldType, ok := oldTypes[t.Field]
+		newType := opts.fieldType(newTableName, t)
+		switch {
+		case !ok:
+			report = append(report, fmt.Sprintf("added: %s (%s) is left as its zero value", t.Field, newType))
+		case oldType != newType:
+			report = append(report, fmt.Sprintf("type changed: %s (%s -> %s)", t.Field, oldType, newType))
+		default:
+			field := opts.fieldName(t.Field)
+			result.WriteString(fmt.Sprintf("\t\t%s: old.%s,\n", field, field))
+		}
+	}
+
+	for _, t := range oldTT {
+		if !newFields[t.Field] {
+			report = append(report, fmt.Sprintf("removed: %s (%s) is dropped", t.Field, oldTypes[t.Field]))
+		}
+	}
+
+	result.WriteString("\t}\n}")
+
+	return result.String(), report
+}
+
+// CreateLogValueMethod generates a `LogValue` method implementing `slog.LogValuer`.
+//
+// The generated method returns a group value with one attribute per column, keyed by
+// the column name. Sensitive columns selected by the policy are replaced by the policy
+// mask, nil pointers are logged as nil, and `[]byte` columns are logged as their length
+// under a `<column>_len` key instead of their content.
+//
+// Parameters:
+//   - tt: []TableDescriptor - A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the table.
+//   - tableName: string - The name of the table, used to derive the receiver struct name.
+//   - policy: *RedactionPolicy - The policy selecting the columns to mask. A nil policy
+//     masks nothing.
+//
+// Returns:
+//   - string: The Go source of the method.
+//
+// Notes:
+//   - The generated code uses the `log/slog` package, which must be imported by the file
+//     it is written to.
+func CreateLogValueMethod(tt []TableDescriptor, tableName string, policy *RedactionPolicy) string {
+	return CreateLogValueMethodWithOptions(tt, tableName, policy, GenerateOptions{})
+}
+
+// CreateLogValueMethodWithOptions behaves like `CreateLogValueMethod` for the struct
+// generated with the given options.
+//
+// Parameters:
+//   - tt: []TableDescriptor - A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the table.
+//   - tableName: string - The name of the table, used to derive the receiver struct name.
+//   - policy: *RedactionPolicy - The policy selecting the columns to mask. A nil policy
+//     masks nothing.
+//   - opts: GenerateOptions - The options the struct was generated with, so the receiver,
+//     field names and field types match it.
+//
+// Returns:
+//   - string: The Go source of the method.
+func CreateLogValueMethodWithOptions(tt []TableDescriptor, tableName string, policy *RedactionPolicy, opts GenerateOptions) string {
+
+	result := strings.Builder{}
+	result.WriteString(fmt.Sprintf("func (x %s) LogValue() slog.Value {\n", opts.structName(tableName)))
+	result.WriteString(fmt.Sprintf("\tattrs := make([]slog.Attr, 0, %d)\n", len(tt)))
+
+	for _, t := range OrderFields(tt, opts.FieldOrder) {
+		field := opts.fieldName(t.Field)
+		fieldType := opts.fieldType(tableName, t)
+
+		switch {
+		case policy.IsSensitive(t.Field):
+			result.WriteString(fmt.Sprintf("\tattrs = append(attrs, slog.String(%q, %q))\n", t.Field, policy.mask()))
+		case fieldType == "[]byte", opts.bytesType(t) != "":
+			result.WriteString(fmt.Sprintf("\tattrs = append(attrs, slog.Int(%q, len(x.%s)))\n", t.Field+"_len", field))
+		case strings.HasPrefix(fieldType, "*"):
+			result.WriteString(fmt.Sprintf("\tif x.%s != nil {\n", field))
+			result.WriteString(fmt.Sprintf("\t\tattrs = append(attrs, slog.Any(%q, *x.%s))\n", t.Field, field))
+			result.WriteString("\t} else {\n")
+			result.WriteString(fmt.Sprintf("\t\tattrs = append(attrs, slog.Any(%q, nil))\n", t.Field))
+			result.WriteString("\t}\n")
+		default:
+			result.WriteString(fmt.Sprintf("\tattrs = append(attrs, slog.Any(%q, x.%s))\n", t.Field, field))
+		}
+	}
+
+	result.WriteString("\treturn slog.GroupValue(attrs...)\n}")
+
+	return result.String()
+}
+
+// Registry identifies the function generated code calls to register models.
+type Registry struct {
+	// ImportPath is the import path of the package declaring the function. An empty
+	// ImportPath means the function is declared in the generated package itself.
+	ImportPath string
+	// Function is the name of a function with the signature `func(name string, proto interface{})`.
+	Function string
+}
+
+// CreateRegistrationInit generates an `init` function registering the struct of every table.
+//
+// The generated `init` calls the registry function once per table with the original
+// table name and a pointer to a zero value of the generated struct. Tables are
+// registered in sorted order so the output is deterministic.
+//
+// Parameters:
+//   - tableNames: []string - The names of the tables whose structs are registered.
+//   - registry: Registry - The function to call, and the package it belongs to.
+//
+// Returns:
+//   - string: The Go source of the `init` function.
+//
+// Notes:
+//   - When `registry.ImportPath` is set, the call is qualified with the last element of
+//     the path, which must be imported by the file the code is written to.
+func CreateRegistrationInit(tableNames []string, registry Registry) string {
+	return CreateRegistrationInitWithOptions(tableNames, registry, GenerateOptions{})
+}
+
+// CreateRegistrationInitWithOptions behaves like `CreateRegistrationInit` for the structs
+// generated with the given options.
+//
+// Parameters:
+//   - tableNames: []string - The names of the tables whose structs are registered.
+//   - registry: Registry - The function to call, and the package it belongs to.
+//   - opts: GenerateOptions - The options the structs were generated with, so the
+//     registered struct names match them. Tables are still registered by their name.
+//
+// Returns:
+//   - string: The Go source of the `init` function.
+func CreateRegistrationInitWithOptions(tableNames []string, registry Registry, opts GenerateOptions) string {
+
+	tables := make([]string, len(tableNames))
+	copy(tables, tableNames)
+	sort.Strings(tables)
+
+	function := registry.Function
+	if registry.ImportPath != "" {
+		function = path.Base(registry.ImportPath) + "." + function
+	}
+
+	result := strings.Builder{}
+	result.WriteString("func init() {\n")
+	for _, t := range tables {
+		result.WriteString(fmt.Sprintf("\t%s(%q, new(%s))\n", function, t, opts.structName(t)))
+	}
+	result.WriteString("}")
+
+	return result.String()
+}
+
+// InterfaceSpec describes a single-method interface satisfied by returning the primary key.
+type InterfaceSpec struct {
+	// ImportPath is the import path of the package declaring the interface. An empty
+	// ImportPath means the interface is declared in the generated package itself.
+	ImportPath string
+	// Name is the name of the interface, e.g. "Identifiable".
+	Name string
+	// Method is the name of the method returning the primary key, e.g. "GetID".
+	Method string
+	// ReturnType is the Go type the method returns, e.g. "int64". Tables whose primary
+	// key maps to another type are skipped. An empty ReturnType accepts any key type.
+	ReturnType string
+}
+
+// CreateInterfaceAssertion generates a primary key accessor and a compile-time interface check.
+//
+// For a table with a single primary key column, this function generates the accessor
+// method described by the spec and a `var _ <Interface> = (*<Struct>)(nil)` assertion, so
+// the build breaks if the generated struct ever stops satisfying the interface.
+//
+// Parameters:
+//   - tt: []TableDescriptor - A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the table.
+//   - tableName: string - The name of the table, used to derive the struct name.
+//   - spec: InterfaceSpec - The interface to satisfy.
+//
+// Returns:
+//   - string: The Go source of the method and the assertion.
+//   - bool: `false` if the table cannot satisfy the interface, because it does not have
+//     exactly one primary key column or its type differs from `spec.ReturnType`. The
+//     source is empty in that case.
+//
+// Example Output:
+//
+//	func (x *UsersData) GetID() int64 {
+//		return x.ID
+//	}
+//
+//	var _ domain.Identifiable = (*UsersData)(nil)
+func CreateInterfaceAssertion(tt []TableDescriptor, tableName string, spec InterfaceSpec) (string, bool) {
+	return CreateInterfaceAssertionWithOptions(tt, tableName, spec, GenerateOptions{})
+}
+
+// CreateInterfaceAssertionWithOptions behaves like `CreateInterfaceAssertion` for the
+// struct generated with the given options.
+//
+// Parameters:
+//   - tt: []TableDescriptor - A slice of `TableDescriptor` objects containing metadata
+//     about the columns of the table.
+//   - tableName: string - The name of the table, used to derive the struct name.
+//   - spec: InterfaceSpec - The interface to satisfy.
+//   - opts: GenerateOptions - The options the struct was generated with, so the struct
+//     name, key field and key type match it.
+//
+// Returns:
+//   - string: The Go source of the method and the assertion.
+//   - bool: `false` if the table cannot satisfy the interface. The source is empty in
+//     that case.
+func CreateInterfaceAssertionWithOptions(tt []TableDescriptor, tableName string, spec InterfaceSpec, opts GenerateOptions) (string, bool) {
+
+	var pk *TableDescriptor
+	for i := range tt {
+		if tt[i].Key == "PRI" {
+			if pk != nil {
+				return "", false
+			}
+			pk = &tt[i]
+		}
+	}
+
+	if pk == nil {
+		return "", false
+	}
+
+	pkType := opts.fieldType(tableName, *pk)
+	if spec.ReturnType != "" && spec.ReturnType != pkType {
+		return "", false
+	}
+
+	iface := spec.Name
+	if spec.ImportPath != "" {
+		iface = path.Base(spec.ImportPath) + "." + iface
+	}
+
+	name := opts.structName(tableName)
+
+	result := strings.Builder{}
+	result.WriteString(fmt.Sprintf("func (x *%s) %s() %s {\n", name, spec.Method, pkType))
+	result.WriteString(fmt.Sprintf("\treturn x.%s\n}\n\n", opts.fieldName(pk.Field)))
+	result.WriteString(fmt.Sprintf("var _ %s = (*%s)(nil)", iface, name))
+
+	return result.String(), true
+}
+
+// UnmappedColumn identifies a column whose type has no known Go mapping.
+type UnmappedColumn struct {
+	Table  string
+	Column string
+	Type   string
+}
+
+// FindUnmappedColumns lists the columns that generation maps to `interface{}`.
+//
+// Unknown column types, such as spatial types, are silently rendered as `interface{}`.
+// This function reports them so callers can warn about them or add a mapping through
+// `GenerateOptions.CustomTypeMap`. It does not change the generated code.
+//
+// Parameters:
+//   - descriptors: map[string][]TableDescriptor - A map where the keys are table names,
+//     and the values are slices of `TableDescriptor` objects containing metadata about
+//     the table columns.
+//   - opts: GenerateOptions - The generation options, so configured mappings are taken
+//     into account.
+//
+// Returns:
+//   - []UnmappedColumn: The unmapped columns, in table name order and then in field order.
+//     The slice is empty if every column is mapped.
+//
+// Example Usage:
+//
+//	for _, c := range FindUnmappedColumns(descriptors, opts) {
+//		log.Printf("%s.%s: no Go type for %s", c.Table, c.Column, c.Type)
+//	}
+func FindUnmappedColumns(descriptors map[string][]TableDescriptor, opts GenerateOptions) []UnmappedColumn {
+
+	result := make([]UnmappedColumn, 0)
+	for _, tableName := range sortedTableNames(descriptors) {
+		for _, t := range OrderFields(descriptors[tableName], opts.FieldOrder) {
+			if strings.TrimLeft(opts.fieldType(tableName, t), "*") == "interface{}" {
+				result = append(result, UnmappedColumn{Table: tableName, Column: t.Field, Type: t.Type})
+			}
+		}
 	}
 
+	return result
 }
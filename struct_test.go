@@ -0,0 +1,1416 @@
+package db2go
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// checkSource parses and type-checks a complete generated Go file, failing the test with
+// the source on any error. Only standard library imports can be resolved.
+func checkSource(t *testing.T, src string) *ast.File {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "generated.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, src)
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("models", fset, []*ast.File{file}, nil); err != nil {
+		t.Fatalf("generated code does not type-check: %v\n%s", err, src)
+	}
+
+	return file
+}
+
+// generateFile returns the complete file generated for the descriptors in package models.
+func generateFile(t *testing.T, descriptors map[string][]TableDescriptor, opts GenerateOptions) string {
+	t.Helper()
+
+	out := strings.Builder{}
+	if err := CreateAllTablesStructToWriter(&out, "models", descriptors, opts); err != nil {
+		t.Fatalf("CreateAllTablesStructToWriter() = %v", err)
+	}
+	return out.String()
+}
+
+// runGenerated compiles a generated file of package models together with the body of a
+// main function, which can use the fmt package, and returns what the program prints. It
+// is skipped in short mode since it runs the go tool.
+func runGenerated(t *testing.T, src string, body string) string {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("skipping the compilation of generated code in short mode")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":       "module generated\n\ngo 1.22\n",
+		"generated.go": strings.Replace(src, "package models\n", "package main\n", 1),
+		"main.go":      "package main\n\nimport \"fmt\"\n\nvar _ = fmt.Sprint\n\nfunc main() {\n" + body + "\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("running generated code failed: %v\n%s\n%s", err, out, src)
+	}
+	return string(out)
+}
+
+// findStruct returns the declaration of a struct in a parsed file.
+func findStruct(t *testing.T, file *ast.File, name string) *ast.StructType {
+	t.Helper()
+
+	var result *ast.StructType
+	ast.Inspect(file, func(n ast.Node) bool {
+		if spec, ok := n.(*ast.TypeSpec); ok && spec.Name.Name == name {
+			result, _ = spec.Type.(*ast.StructType)
+		}
+		return result == nil
+	})
+	if result == nil {
+		t.Fatalf("struct %s not found", name)
+	}
+	return result
+}
+
+// structFields returns the field names and types of a struct declared in a parsed file.
+func structFields(t *testing.T, file *ast.File, name string) map[string]string {
+	t.Helper()
+
+	result := make(map[string]string)
+	for _, f := range findStruct(t, file, name).Fields.List {
+		for _, n := range f.Names {
+			result[n.Name] = types.ExprString(f.Type)
+		}
+	}
+	return result
+}
+
+// fieldTags returns the tags of the fields of a struct declared in a parsed file.
+func fieldTags(t *testing.T, file *ast.File, name string) map[string]reflect.StructTag {
+	t.Helper()
+
+	result := make(map[string]reflect.StructTag)
+	for _, f := range findStruct(t, file, name).Fields.List {
+		if f.Tag == nil {
+			continue
+		}
+		tag, err := strconv.Unquote(f.Tag.Value)
+		if err != nil {
+			t.Fatalf("malformed tag %s: %v", f.Tag.Value, err)
+		}
+		for _, n := range f.Names {
+			result[n.Name] = reflect.StructTag(tag)
+		}
+	}
+	return result
+}
+
+func TestWithNullTime(t *testing.T) {
+
+	descriptors := map[string][]TableDescriptor{
+		"events": {
+			{Field: "id", Type: "bigint", Null: "NO", Key: "PRI"},
+			{Field: "starts_at", Type: "datetime", Null: "NO"},
+			{Field: "ends_at", Type: "datetime(6)", Null: "YES"},
+			{Field: "cancelled_at", Type: "timestamp", Null: "YES"},
+		},
+		"logs": {
+			{Field: "created_at", Type: "timestamp", Null: "YES"},
+		},
+	}
+
+	for _, strategy := range []NullStrategy{NullPointer, NullSQL} {
+		src := generateFile(t, descriptors, GenerateOptions{WithNullTime: true, NullStrategy: strategy})
+		file := checkSource(t, src)
+
+		fields := structFields(t, file, "EventsData")
+		want := map[string]string{"ID": "int64", "StartsAt": "time.Time", "EndsAt": "NullTime", "CancelledAt": "NullTime"}
+		for name, typ := range want {
+			if fields[name] != typ {
+				t.Errorf("strategy %d: field %s has type %s, want %s", strategy, name, fields[name], typ)
+			}
+		}
+		if n := strings.Count(src, "type NullTime struct"); n != 1 {
+			t.Errorf("strategy %d: NullTime is declared %d times, want once", strategy, n)
+		}
+	}
+
+	src := generateFile(t, descriptors, GenerateOptions{})
+	if strings.Contains(src, "NullTime") {
+		t.Errorf("NullTime is generated without WithNullTime:\n%s", src)
+	}
+}
+
+// protoComment matches a struct field annotated with its protobuf field number.
+var protoComment = regexp.MustCompile(`(?m)^\s*(\w+)\s.*// proto: (\d+)$`)
+
+func TestWithProtoNumbers(t *testing.T) {
+
+	tests := []struct {
+		name string
+		tt   []TableDescriptor
+		want map[string]int
+	}{
+		{
+			name: "descriptor position",
+			tt: []TableDescriptor{
+				{Field: "name", Type: "varchar(20)", Null: "NO"},
+				{Field: "id", Type: "int", Null: "NO", Key: "PRI"},
+			},
+			want: map[string]int{"Name": 1, "ID": 2},
+		},
+		{
+			name: "ordinal position",
+			tt: []TableDescriptor{
+				{Field: "name", Type: "varchar(20)", Null: "NO", OrdinalPosition: 3},
+				{Field: "id", Type: "int", Null: "NO", Key: "PRI", OrdinalPosition: 1},
+			},
+			want: map[string]int{"Name": 3, "ID": 1},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Keys first moves id before name, which must not change the numbers
+			src := CreateStructWithOptions(tc.tt, "users", GenerateOptions{WithProtoNumbers: true, WithJSON: true, FieldOrder: FieldOrderKeysFirst})
+			checkSource(t, "package models\n\n"+src)
+
+			got := make(map[string]int)
+			for _, m := range protoComment.FindAllStringSubmatch(src, -1) {
+				number, _ := strconv.Atoi(m[2])
+				got[m[1]] = number
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("proto numbers = %v, want %v\n%s", got, tc.want, src)
+			}
+		})
+	}
+
+	if src := CreateStruct([]TableDescriptor{{Field: "id", Type: "int", Null: "NO"}}, "users", false); strings.Contains(src, "proto:") {
+		t.Errorf("proto numbers are generated without WithProtoNumbers:\n%s", src)
+	}
+}
+
+func TestKeywordFieldSuffix(t *testing.T) {
+
+	descriptors := map[string][]TableDescriptor{
+		"items": {
+			{Field: "id", Type: "int", Null: "NO", Key: "PRI"},
+			{Field: "type", Type: "varchar(20)", Null: "NO"},
+			{Field: "default", Type: "varchar(20)", Null: "YES"},
+			{Field: "type_name", Type: "varchar(20)", Null: "NO"},
+		},
+	}
+
+	tests := []struct {
+		suffix string
+		want   map[string]string
+	}{
+		{suffix: "", want: map[string]string{"type": "Type", "default": "Default", "type_name": "TypeName"}},
+		{suffix: "Kind", want: map[string]string{"type": "TypeKind", "default": "DefaultKind", "type_name": "TypeName"}},
+		{suffix: "_", want: map[string]string{"type": "Type_", "default": "Default_", "type_name": "TypeName"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.suffix, func(t *testing.T) {
+			opts := GenerateOptions{KeywordFieldSuffix: tc.suffix, WithDBTags: true, WithConstructors: true, WithStringer: true}
+			tags := fieldTags(t, checkSource(t, generateFile(t, descriptors, opts)), "ItemsData")
+
+			for column, field := range tc.want {
+				if got := tags[field].Get("db"); got != column {
+					t.Errorf("column %s: field %s has db tag %q, want %q", column, field, got, column)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderFields(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		tt    []TableDescriptor
+		order FieldOrder
+		want  string
+	}{
+		{
+			name: "schema order",
+			tt:   []TableDescriptor{{Field: "b"}, {Field: "a", Key: "PRI"}, {Field: "c", Key: "MUL"}},
+			want: "b a c",
+		},
+		{
+			name:  "keys first",
+			tt:    []TableDescriptor{{Field: "b"}, {Field: "a", Key: "PRI"}, {Field: "c", Key: "MUL", References: "teams.id"}, {Field: "d"}},
+			order: FieldOrderKeysFirst,
+			want:  "a c b d",
+		},
+		{
+			name:  "indexed columns are not foreign keys",
+			tt:    []TableDescriptor{{Field: "b", Key: "MUL"}, {Field: "a", Key: "PRI"}, {Field: "c", References: "teams.id"}, {Field: "d", Key: "UNI"}},
+			order: FieldOrderKeysFirst,
+			want:  "a c b d",
+		},
+		{
+			name: "ordinal positions",
+			tt:   []TableDescriptor{{Field: "b", OrdinalPosition: 2}, {Field: "c", OrdinalPosition: 3}, {Field: "a", OrdinalPosition: 1}},
+			want: "a b c",
+		},
+		{
+			name:  "keys first by ordinal position",
+			tt:    []TableDescriptor{{Field: "d", OrdinalPosition: 4}, {Field: "b", OrdinalPosition: 2, Key: "PRI"}, {Field: "a", OrdinalPosition: 1, Key: "PRI"}, {Field: "c", OrdinalPosition: 3}},
+			order: FieldOrderKeysFirst,
+			want:  "a b c d",
+		},
+		{
+			name: "partial ordinal positions are ignored",
+			tt:   []TableDescriptor{{Field: "b", OrdinalPosition: 2}, {Field: "a"}},
+			want: "b a",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			input := append([]TableDescriptor(nil), tc.tt...)
+			got := make([]string, 0)
+			for _, c := range OrderFields(tc.tt, tc.order) {
+				got = append(got, c.Field)
+			}
+			if strings.Join(got, " ") != tc.want {
+				t.Errorf("OrderFields() = %v, want %s", got, tc.want)
+			}
+			if !reflect.DeepEqual(input, tc.tt) {
+				t.Errorf("OrderFields() modified its input: %v", tc.tt)
+			}
+		})
+	}
+}
+
+func TestProjectColumns(t *testing.T) {
+
+	tt := []TableDescriptor{
+		{Field: "id", Type: "bigint", Null: "NO", Key: "PRI"},
+		{Field: "email", Type: "varchar(255)", Null: "NO"},
+		{Field: "name", Type: "varchar(64)", Null: "YES"},
+		{Field: "created_at", Type: "datetime", Null: "NO"},
+	}
+	input := append([]TableDescriptor(nil), tt...)
+
+	projected, err := ProjectColumns(tt, []string{"email", "id", "created_at"})
+	if err != nil {
+		t.Fatalf("ProjectColumns() = %v", err)
+	}
+	want := []TableDescriptor{tt[1], tt[0], tt[3]}
+	if !reflect.DeepEqual(projected, want) {
+		t.Errorf("ProjectColumns() = %+v, want %+v", projected, want)
+	}
+	if !reflect.DeepEqual(input, tt) {
+		t.Errorf("ProjectColumns() modified its input: %v", tt)
+	}
+
+	// The struct fields follow the projection, so a scan in SELECT order lines up
+	file := checkSource(t, "package models\n\nimport \"time\"\n\n"+CreateStruct(projected, "users", false))
+	fields := make([]string, 0)
+	for _, f := range findStruct(t, file, "UsersData").Fields.List {
+		fields = append(fields, f.Names[0].Name)
+	}
+	if strings.Join(fields, " ") != "Email ID CreatedAt" {
+		t.Errorf("struct fields = %v, want Email ID CreatedAt", fields)
+	}
+
+	_, err = ProjectColumns(tt, []string{"id", "nickname", "phone"})
+	if err == nil || err.Error() != "column nickname does not exist" {
+		t.Errorf("ProjectColumns() = %v, want an error naming nickname", err)
+	}
+}
+
+func TestEnumTypeStringAndParse(t *testing.T) {
+
+	descriptors := map[string][]TableDescriptor{
+		"users": {
+			{Field: "id", Type: "int", Null: "NO", Key: "PRI"},
+			{Field: "status", Type: "enum('active','inactive','it''s')", Null: "NO"},
+		},
+	}
+	src := generateFile(t, descriptors, GenerateOptions{WithEnumTypes: true})
+	checkSource(t, src)
+
+	for _, want := range []string{"func (e UsersStatus) String() string", "func ParseUsersStatus(s string) (UsersStatus, error)"} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated code does not contain %q:\n%s", want, src)
+		}
+	}
+
+	out := runGenerated(t, src, `
+	for _, s := range []string{"active", "it's", "deleted", ""} {
+		v, err := ParseUsersStatus(s)
+		fmt.Printf("%q %v\n", v.String(), err)
+	}
+	fmt.Println(UsersStatusInactive)`)
+
+	want := `"active" <nil>
+"it's" <nil>
+"" unknown UsersStatus value "deleted"
+"" unknown UsersStatus value ""
+inactive
+`
+	if out != want {
+		t.Errorf("generated enum printed:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestRedaction(t *testing.T) {
+
+	descriptors := map[string][]TableDescriptor{
+		"accounts": {
+			{Field: "id", Type: "int", Null: "NO", Key: "PRI"},
+			{Field: "email", Type: "varchar(255)", Null: "NO"},
+			{Field: "password_hash", Type: "varchar(255)", Null: "NO"},
+			{Field: "api_key", Type: "varchar(64)", Null: "YES"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		policy    *RedactionPolicy
+		sensitive []string
+		want      string
+	}{
+		{name: "no policy", want: "AccountsData{ID=1 Email=a@b.c PasswordHash=hash APIKey=key}"},
+		{
+			name:      "default matcher",
+			policy:    &RedactionPolicy{},
+			sensitive: []string{"PasswordHash", "APIKey"},
+			want:      "AccountsData{ID=1 Email=a@b.c PasswordHash=*** APIKey=***}",
+		},
+		{
+			name:      "custom matcher and mask",
+			policy:    &RedactionPolicy{Matcher: regexp.MustCompile(`^email$`), Mask: "[hidden]"},
+			sensitive: []string{"Email"},
+			want:      "AccountsData{ID=1 Email=[hidden] PasswordHash=hash APIKey=key}",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			src := generateFile(t, descriptors, GenerateOptions{WithStringer: true, Redaction: tc.policy})
+			tags := fieldTags(t, checkSource(t, src), "AccountsData")
+
+			sensitive := make(map[string]bool)
+			for _, f := range tc.sensitive {
+				sensitive[f] = true
+			}
+			for _, f := range []string{"ID", "Email", "PasswordHash", "APIKey"} {
+				if got := tags[f].Get("redact") == "true"; got != sensitive[f] {
+					t.Errorf("field %s has redact tag %v, want %v", f, got, sensitive[f])
+				}
+			}
+
+			out := runGenerated(t, src, `
+	key := "key"
+	fmt.Println(AccountsData{ID: 1, Email: "a@b.c", PasswordHash: "hash", APIKey: &key})`)
+			if strings.TrimSpace(out) != tc.want {
+				t.Errorf("String() = %s, want %s", out, tc.want)
+			}
+		})
+	}
+}
+
+func TestFractionalSecondsMapToTime(t *testing.T) {
+
+	tests := []struct {
+		dbType string
+		null   string
+		want   string
+	}{
+		{"datetime(6)", "NO", "time.Time"},
+		{"DATETIME(6)", "NO", "time.Time"},
+		{"timestamp(6)", "NO", "time.Time"},
+		{"TIMESTAMP(3)", "NO", "time.Time"},
+		{"datetime(6)", "YES", "*time.Time"},
+		{"timestamp(6)", "YES", "*time.Time"},
+		{"time(6)", "NO", "time.Time"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.dbType+" "+tc.null, func(t *testing.T) {
+			if got := getType(TableDescriptor{Field: "at", Type: tc.dbType, Null: tc.null}); got != tc.want {
+				t.Errorf("getType(%s) = %s, want %s", tc.dbType, got, tc.want)
+			}
+		})
+	}
+
+	descriptors := map[string][]TableDescriptor{
+		"events": {
+			{Field: "created_at", Type: "datetime(6)", Null: "NO"},
+			{Field: "updated_at", Type: "timestamp(6)", Null: "YES"},
+		},
+	}
+	fields := structFields(t, checkSource(t, generateFile(t, descriptors, GenerateOptions{})), "EventsData")
+	if fields["CreatedAt"] != "time.Time" || fields["UpdatedAt"] != "*time.Time" {
+		t.Errorf("fields = %v, want CreatedAt time.Time and UpdatedAt *time.Time", fields)
+	}
+}
+
+func TestWithOptionsVariants(t *testing.T) {
+
+	users := []TableDescriptor{
+		{Field: "id", Type: "bigint", Null: "NO", Key: "PRI"},
+		{Field: "email", Type: "varchar(255)", Null: "YES"},
+		{Field: "type", Type: "varchar(20)", Null: "NO"},
+	}
+	oldUsers := users[:2]
+	opts := GenerateOptions{
+		Singularize:        true,
+		StructSuffix:       "Row",
+		NullStrategy:       NullSQL,
+		KeywordFieldSuffix: "Value",
+	}
+
+	batch, err := CreateBatchGetByIDsWithOptions(users, "users", 100, opts)
+	if err != nil {
+		t.Fatalf("CreateBatchGetByIDsWithOptions() = %v", err)
+	}
+	mapper, report := CreateMapperWithOptions(oldUsers, "old_users", users, "users", opts)
+	if len(report) != 1 || !strings.Contains(report[0], "added: type") {
+		t.Errorf("CreateMapperWithOptions() report = %q, want the added type column", report)
+	}
+	assertion, ok := CreateInterfaceAssertionWithOptions(users, "users", InterfaceSpec{Name: "Identifiable", Method: "GetID", ReturnType: "int64"}, opts)
+	if !ok {
+		t.Fatal("CreateInterfaceAssertionWithOptions() = false, want true")
+	}
+
+	src := strings.Join([]string{
+		"package models",
+		"import (\n\t\"context\"\n\t\"database/sql\"\n\t\"log/slog\"\n\t\"strings\"\n)",
+		"type Identifiable interface{ GetID() int64 }",
+		"func register(name string, proto interface{}) {}",
+		CreateStructWithOptions(users, "users", opts),
+		CreateStructWithOptions(oldUsers, "old_users", opts),
+		CreateFieldColumnMapWithOptions(users, "users", opts),
+		batch,
+		mapper,
+		CreateLogValueMethodWithOptions(users, "users", &RedactionPolicy{}, opts),
+		CreateRegistrationInitWithOptions([]string{"users", "old_users"}, Registry{Function: "register"}, opts),
+		assertion,
+		CreateColumnCountConstWithOptions(users, "users", opts),
+		"var _ = UserColumnCount\nvar _ context.Context",
+	}, "\n\n")
+	checkSource(t, src)
+
+	if !strings.Contains(src, `"TypeValue": "type"`) {
+		t.Errorf("CreateFieldColumnMapWithOptions() does not use the field names of the options:\n%s", src)
+	}
+}
+
+func TestStructNameCollisions(t *testing.T) {
+
+	columns := []TableDescriptor{{Field: "id", Type: "int", Null: "NO", Key: "PRI"}}
+
+	tests := []struct {
+		name   string
+		tables []string
+		opts   GenerateOptions
+		want   string
+	}{
+		{
+			name:   "trimmed prefix",
+			tables: []string{"app_users", "users"},
+			opts:   GenerateOptions{TrimTablePrefix: []string{"app_"}},
+			want:   "tables app_users and users both generate struct UsersData",
+		},
+		{
+			name:   "singularized",
+			tables: []string{"user", "users"},
+			opts:   GenerateOptions{Singularize: true},
+			want:   "tables user and users both generate struct UserData",
+		},
+		{
+			name:   "trimmed and singularized",
+			tables: []string{"tbl_category", "categories"},
+			opts:   GenerateOptions{TrimTablePrefix: []string{"tbl_"}, Singularize: true},
+			want:   "tables categories and tbl_category both generate struct CategoryData",
+		},
+		{
+			name:   "distinct",
+			tables: []string{"app_users", "users"},
+			opts:   GenerateOptions{Singularize: true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+
+			descriptors := make(map[string][]TableDescriptor)
+			for _, table := range tc.tables {
+				descriptors[table] = columns
+			}
+			tc.opts.PackageName = "models"
+
+			check := func(function string, err error) {
+				t.Helper()
+				switch {
+				case tc.want == "" && err != nil:
+					t.Errorf("%s() = %v, want nil", function, err)
+				case tc.want != "" && (err == nil || err.Error() != tc.want):
+					t.Errorf("%s() = %v, want %q", function, err, tc.want)
+				}
+			}
+
+			check("CreateAllTablesStructToWriter", CreateAllTablesStructToWriter(io.Discard, "models", descriptors, tc.opts))
+			check("CreateStructFilesPerTable", CreateStructFilesPerTable(t.TempDir(), "models", descriptors, tc.opts))
+			_, err := GenerateStructs(descriptors, tc.opts)
+			check("GenerateStructs", err)
+		})
+	}
+}
+
+func TestAppendToFile(t *testing.T) {
+
+	filename := filepath.Join(t.TempDir(), "models.go")
+	opts := GenerateOptions{
+		PackageName:   "models",
+		AppendToFile:  true,
+		WithNullTime:  true,
+		WithEnumTypes: true,
+		BytesTypes:    []BytesType{{Matcher: regexp.MustCompile("_hash$"), Name: "Hash"}},
+	}
+
+	users := map[string][]TableDescriptor{
+		"users": {
+			{Field: "id", Type: "int", Null: "NO", Key: "PRI"},
+			{Field: "created_at", Type: "datetime", Null: "NO"},
+			{Field: "deleted_at", Type: "datetime", Null: "YES"},
+			{Field: "password_hash", Type: "varbinary(32)", Null: "NO"},
+			{Field: "status", Type: "enum('active','banned')", Null: "NO"},
+		},
+	}
+	orders := map[string][]TableDescriptor{
+		"orders": {
+			{Field: "id", Type: "int", Null: "NO", Key: "PRI"},
+			{Field: "placed_at", Type: "datetime", Null: "NO"},
+			{Field: "shipped_at", Type: "datetime", Null: "YES"},
+			{Field: "receipt_hash", Type: "varbinary(32)", Null: "NO"},
+			{Field: "status", Type: "enum('open','paid')", Null: "NO"},
+		},
+	}
+
+	for _, descriptors := range []map[string][]TableDescriptor{users, orders} {
+		if err := WriteStructsToFile(filename, descriptors, opts); err != nil {
+			t.Fatalf("WriteStructsToFile() = %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(content)
+
+	for _, once := range []string{"package models\n", "// Code generated", "type NullTime struct", "func (t *NullTime) Scan(",
+		"type Hash []byte", "func ParseHashHex(", "type UsersStatus string", "type OrdersStatus string"} {
+		if n := strings.Count(src, once); n != 1 {
+			t.Errorf("%q appears %d times, want once:\n%s", once, n, src)
+		}
+	}
+
+	file := checkSource(t, src)
+	findStruct(t, file, "UsersData")
+	findStruct(t, file, "OrdersData")
+
+	err = WriteStructsToFile(filename, users, opts)
+	if err == nil || !strings.Contains(err.Error(), "already declares struct UsersData of table users") {
+		t.Errorf("WriteStructsToFile() of an appended table = %v, want an error naming the struct", err)
+	}
+	if again, _ := os.ReadFile(filename); string(again) != src {
+		t.Errorf("the failed append changed the file")
+	}
+}
+
+func TestDropDeclared(t *testing.T) {
+
+	body := "// A is kept.\ntype A int\n\n// B is dropped.\ntype B int\n\nfunc (b *B) M() {}\n\nfunc (a A) M() {}\n\nconst (\n\tC = 1\n\tD = 2\n)\n\n"
+	got, err := dropDeclared(body, map[string]bool{"B": true, "B.M": true, "A.N": true, "C": true})
+	if err != nil {
+		t.Fatalf("dropDeclared() = %v", err)
+	}
+
+	want := "// A is kept.\ntype A int\n\nfunc (a A) M() {}\n\nconst (\n\tC = 1\n\tD = 2\n)\n\n"
+	if got != want {
+		t.Errorf("dropDeclared() = %q, want %q", got, want)
+	}
+}
+
+func TestFieldsNamedAfterMethods(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		column string
+		opts   GenerateOptions
+		method string
+		want   string
+	}{
+		{name: "TableName", column: "table_name", opts: GenerateOptions{WithTableNameMethod: true}, want: "TableName_"},
+		{name: "TableName without method", column: "table_name", want: "TableName"},
+		{name: "String", column: "string", opts: GenerateOptions{WithStringer: true}, want: "String_"},
+		{name: "String without method", column: "string", want: "String"},
+		{name: "LogValue", column: "log_value", method: "LogValue", want: "LogValue_"},
+		{name: "FieldColumnMap", column: "field_column_map", method: "FieldColumnMap", want: "FieldColumnMap_"},
+		{name: "IsView", column: "is_view", method: "IsView", want: "IsView_"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+
+			tt := []TableDescriptor{
+				{Field: "id", Type: "int", Null: "NO", Key: "PRI"},
+				{Field: tc.column, Type: "varchar(20)", Null: "NO"},
+			}
+
+			src := generateFile(t, map[string][]TableDescriptor{"items": tt}, tc.opts)
+			switch tc.method {
+			case "LogValue":
+				src = strings.Replace(src, "package models\n", "package models\n\nimport \"log/slog\"\n", 1)
+				src += CreateLogValueMethodWithOptions(tt, "items", nil, tc.opts)
+			case "FieldColumnMap":
+				src += CreateFieldColumnMapWithOptions(tt, "items", tc.opts)
+			case "IsView":
+				tt[0].View = true
+				tt[1].View = true
+				src = generateFile(t, map[string][]TableDescriptor{"items": tt}, tc.opts)
+			}
+
+			fields := structFields(t, checkSource(t, src), "ItemsData")
+			if _, ok := fields[tc.want]; !ok {
+				t.Errorf("fields = %v, want %s", fields, tc.want)
+			}
+		})
+	}
+}
+
+func TestCreateStructFilesPerTableFileNames(t *testing.T) {
+
+	tests := []struct {
+		table string
+		want  string
+	}{
+		{"users", "users.go"},
+		{"UserLogs", "user_logs.go"},
+		{"_migrations", "migrations.go"},
+		{".hidden", "hidden.go"},
+		{"users_test", "users_test_model.go"},
+		{"events_linux", "events_linux_model.go"},
+		{"builds_amd64", "builds_amd64_model.go"},
+		{"builds_windows_arm64", "builds_windows_arm64_model.go"},
+		{"linux", "linux.go"},
+		{"latest", "latest.go"},
+	}
+
+	descriptors := make(map[string][]TableDescriptor)
+	for _, tc := range tests {
+		descriptors[tc.table] = []TableDescriptor{{Field: "id", Type: "int", Null: "NO", Key: "PRI"}}
+		t.Run(tc.table, func(t *testing.T) {
+			if got := tableFileName(tc.table); got != tc.want {
+				t.Errorf("tableFileName(%q) = %q, want %q", tc.table, got, tc.want)
+			}
+		})
+	}
+
+	dir := t.TempDir()
+	if err := CreateStructFilesPerTable(dir, "models", descriptors, GenerateOptions{}); err != nil {
+		t.Fatalf("CreateStructFilesPerTable() = %v", err)
+	}
+	for _, tc := range tests {
+		if _, err := os.Stat(filepath.Join(dir, tc.want)); err != nil {
+			t.Errorf("file of table %s: %v", tc.table, err)
+		}
+	}
+}
+
+// failingWriter is an io.Writer that always fails.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestPrimaryKeysAreNeverPointers(t *testing.T) {
+
+	tests := []struct {
+		column TableDescriptor
+		want   string
+	}{
+		{column: TableDescriptor{Field: "id", Type: "bigint", Null: "YES", Key: "PRI"}, want: "int64"},
+		{column: TableDescriptor{Field: "id", Type: "int", Null: "NO", Key: "PRI"}, want: "int32"},
+		{column: TableDescriptor{Field: "code", Type: "varchar(36)", Null: "YES", Key: "PRI"}, want: "string"},
+		{column: TableDescriptor{Field: "parent_id", Type: "bigint", Null: "YES", Key: "MUL"}, want: "*int64"},
+		{column: TableDescriptor{Field: "note", Type: "varchar(36)", Null: "YES"}, want: "*string"},
+	}
+
+	for _, tc := range tests {
+		if got := getType(tc.column); got != tc.want {
+			t.Errorf("getType(%s %s null=%s key=%s) = %s, want %s", tc.column.Field, tc.column.Type, tc.column.Null, tc.column.Key, got, tc.want)
+		}
+	}
+}
+
+func TestCreateStructParses(t *testing.T) {
+
+	tt := []TableDescriptor{
+		{Field: "id", Type: "bigint(20) unsigned", Null: "NO", Key: "PRI", Extra: "auto_increment"},
+		{Field: "user_name", Type: "varchar(50)", Null: "NO"},
+		{Field: "email", Type: "varchar(255)", Null: "YES"},
+		{Field: "created_at", Type: "datetime", Null: "NO"},
+		{Field: "type", Type: "varchar(10)", Null: "NO"},
+	}
+	wantFields := map[string]string{
+		"ID":        "uint64",
+		"UserName":  "string",
+		"Email":     "*string",
+		"CreatedAt": "time.Time",
+		"Type":      "string",
+	}
+	wantJSON := map[string]string{
+		"ID":        "id",
+		"UserName":  "user_name",
+		"Email":     "email",
+		"CreatedAt": "created_at",
+		"Type":      "type",
+	}
+
+	for _, withJSON := range []bool{true, false} {
+		t.Run(fmt.Sprintf("withJson=%t", withJSON), func(t *testing.T) {
+			src := "package models\n\nimport \"time\"\n\n" + CreateStruct(tt, "users", withJSON)
+			file, err := parser.ParseFile(token.NewFileSet(), "generated.go", src, 0)
+			if err != nil {
+				t.Fatalf("generated code does not parse: %v\n%s", err, src)
+			}
+
+			if got := structFields(t, file, "UsersData"); !reflect.DeepEqual(got, wantFields) {
+				t.Errorf("fields = %v, want %v", got, wantFields)
+			}
+
+			tags := fieldTags(t, file, "UsersData")
+			if !withJSON {
+				if len(tags) > 0 {
+					t.Errorf("fields have tags without JSON: %v", tags)
+				}
+				return
+			}
+			for field, want := range wantJSON {
+				if got := tags[field].Get("json"); got != want {
+					t.Errorf("json tag of %s = %q, want %q", field, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRegenerationTruncates(t *testing.T) {
+
+	descriptors := map[string][]TableDescriptor{"items": {{Field: "id", Type: "int", Null: "NO"}}}
+	opts := GenerateOptions{PackageName: "models"}
+
+	filename := filepath.Join(t.TempDir(), "models.go")
+	if err := os.WriteFile(filename, []byte(strings.Repeat("// stale content\n", 100)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := WriteStructsToFile(filename, descriptors, opts); err != nil {
+			t.Fatalf("WriteStructsToFile() = %v", err)
+		}
+	}
+
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := generateFile(t, descriptors, opts); string(got) != want {
+		t.Errorf("file content after regenerating twice:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+
+	descriptors := map[string][]TableDescriptor{"items": {{Field: "id", Type: "int", Null: "NO"}}}
+	opts := GenerateOptions{PackageName: "models"}
+
+	t.Run("missing directory", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "missing", "models.go")
+		err := WriteStructsToFile(filename, descriptors, opts)
+		if !errors.Is(err, fs.ErrNotExist) || !strings.Contains(err.Error(), filename) {
+			t.Errorf("WriteStructsToFile() = %v, want an error naming %s", err, filename)
+		}
+	})
+
+	t.Run("failing writer", func(t *testing.T) {
+		err := CreateAllTablesStructToWriter(failingWriter{}, "models", descriptors, opts)
+		if err == nil || err.Error() != "failed writing generated structs: disk full" {
+			t.Errorf("CreateAllTablesStructToWriter() = %v", err)
+		}
+	})
+
+	t.Run("invalid package panics", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "models.go")
+		defer func() {
+			if recover() == nil {
+				t.Error("CreateAllTablesStructFileWithOptions() did not panic")
+			}
+			if _, err := os.Stat(filename); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("file written for an invalid package: %v", err)
+			}
+		}()
+		CreateAllTablesStructFileWithOptions(filename, descriptors, GenerateOptions{PackageName: "my-models"})
+	})
+}
+
+func TestDeterministicOutput(t *testing.T) {
+
+	descriptors := make(map[string][]TableDescriptor)
+	for _, name := range []string{"orders", "accounts", "zones", "items", "users", "carts"} {
+		descriptors[name] = []TableDescriptor{{Field: "id", Type: "int", Null: "NO", Key: "PRI"}}
+	}
+	opts := GenerateOptions{WithColumnConstants: true, WithQueryHelpers: true}
+
+	first := generateFile(t, descriptors, opts)
+	for i := 0; i < 20; i++ {
+		if got := generateFile(t, descriptors, opts); got != first {
+			t.Fatalf("generation %d differs:\n%s\nfirst:\n%s", i, got, first)
+		}
+	}
+
+	last := -1
+	for _, name := range []string{"AccountsData", "CartsData", "ItemsData", "OrdersData", "UsersData", "ZonesData"} {
+		i := strings.Index(first, "type "+name+" struct")
+		if i <= last {
+			t.Fatalf("struct %s is not in table name order:\n%s", name, first)
+		}
+		last = i
+	}
+}
+
+func TestSetTypeScanValue(t *testing.T) {
+
+	descriptors := map[string][]TableDescriptor{
+		"users": {
+			{Field: "id", Type: "int", Null: "NO", Key: "PRI"},
+			{Field: "tags", Type: "set('admin','beta','staff')", Null: "NO"},
+		},
+	}
+	src := generateFile(t, descriptors, GenerateOptions{WithSetTypes: true})
+	file := checkSource(t, src)
+
+	if got := structFields(t, file, "UsersData")["Tags"]; got != "UsersTags" {
+		t.Errorf("Tags has type %s, want UsersTags", got)
+	}
+
+	out := runGenerated(t, src, `
+	for _, src := range []interface{}{[]byte("admin,staff"), "beta", "", nil, 42} {
+		var s UsersTags
+		err := s.Scan(src)
+		v, _ := s.Value()
+		fmt.Printf("%d %q %v\n", len(s), v, err)
+	}`)
+
+	want := `2 "admin,staff" <nil>
+1 "beta" <nil>
+0 "" <nil>
+0 "" <nil>
+0 "" cannot scan int into UsersTags
+`
+	if out != want {
+		t.Errorf("generated set type printed:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestBytesTypes(t *testing.T) {
+
+	descriptors := map[string][]TableDescriptor{
+		"files": {
+			{Field: "id", Type: "int", Null: "NO", Key: "PRI"},
+			{Field: "content_hash", Type: "binary(32)", Null: "NO"},
+			{Field: "thumbnail", Type: "blob", Null: "YES"},
+			{Field: "external_hash", Type: "varbinary(64)", Null: "YES"},
+		},
+		"commits": {
+			{Field: "id", Type: "int", Null: "NO", Key: "PRI"},
+			{Field: "parent_hash", Type: "binary(32)", Null: "YES"},
+			{Field: "uuid", Type: "binary(16)", Null: "NO"},
+		},
+	}
+	opts := GenerateOptions{
+		WithStringer: true,
+		BytesTypes: []BytesType{
+			{Matcher: regexp.MustCompile("_hash$"), Name: "HashBytes"},
+			{Matcher: regexp.MustCompile("^uuid$"), Name: "UUIDBytes"},
+		},
+		// VARBINARY columns are mapped to string first, so they are not binary anymore
+		CustomTypeMap: map[string]string{"VARBINARY": "string"},
+	}
+	src := generateFile(t, descriptors, opts)
+	file := checkSource(t, src)
+
+	wantFields := map[string]map[string]string{
+		"FilesData":   {"ID": "int32", "ContentHash": "HashBytes", "Thumbnail": "[]byte", "ExternalHash": "*string"},
+		"CommitsData": {"ID": "int32", "ParentHash": "HashBytes", "UUID": "UUIDBytes"},
+	}
+	for name, want := range wantFields {
+		if got := structFields(t, file, name); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s fields = %v, want %v", name, got, want)
+		}
+	}
+	if n := strings.Count(src, "type HashBytes []byte"); n != 1 {
+		t.Errorf("HashBytes is declared %d times, want once:\n%s", n, src)
+	}
+
+	out := runGenerated(t, src, `
+	h, err := ParseHashBytesHex("00ff10")
+	fmt.Println(h.Hex(), len(h), err)
+	_, err = ParseHashBytesHex("zz")
+	fmt.Println(err != nil)
+
+	raw := []byte{1, 2}
+	var scanned HashBytes
+	err = scanned.Scan(raw)
+	raw[0] = 9
+	fmt.Println(scanned.Hex(), err)
+	fmt.Println(scanned.Scan(nil), scanned == nil, scanned.Scan(42))
+
+	v, _ := HashBytes(nil).Value()
+	w, _ := h.Value()
+	fmt.Printf("%v %x\n", v, w)
+	fmt.Println(CommitsData{ID: 1, ParentHash: h, UUID: UUIDBytes{0xab}})`)
+
+	want := `00ff10 3 <nil>
+true
+0102 <nil>
+<nil> true cannot scan int into HashBytes
+<nil> 00ff10
+CommitsData{ID=1 ParentHash=00ff10 UUID=ab}
+`
+	if out != want {
+		t.Errorf("generated bytes types printed:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestCreateSetBitmaskType(t *testing.T) {
+
+	column := TableDescriptor{Field: "perms", Type: "set('read','write','delete-all')", Null: "NO"}
+	body, err := CreateSetBitmaskType(column, "Perms")
+	if err != nil {
+		t.Fatalf("CreateSetBitmaskType() = %v", err)
+	}
+	src := "package models\n\nimport (\n\t\"database/sql/driver\"\n\t\"fmt\"\n\t\"strings\"\n)\n\n" + body
+	checkSource(t, src)
+
+	// Members get one bit each in definition order, and invalid identifiers a positional name
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "generated.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("models", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"PermsRead": "1", "PermsWrite": "2", "PermsMember3": "4"} {
+		c, ok := pkg.Scope().Lookup(name).(*types.Const)
+		if !ok {
+			t.Errorf("constant %s is not declared:\n%s", name, src)
+			continue
+		}
+		if got := c.Val().String(); got != want || c.Type().String() != "models.Perms" {
+			t.Errorf("%s = %s %s, want %s models.Perms", name, c.Type(), got, want)
+		}
+	}
+
+	for _, tc := range []struct {
+		column  TableDescriptor
+		wantErr string
+	}{
+		{column: TableDescriptor{Field: "status", Type: "enum('a','b')"}, wantErr: "column status is not a SET"},
+		{column: TableDescriptor{Field: "flags", Type: "set()"}, wantErr: "column flags has no SET members"},
+	} {
+		if _, err := CreateSetBitmaskType(tc.column, "Flags"); err == nil || err.Error() != tc.wantErr {
+			t.Errorf("CreateSetBitmaskType(%s) = %v, want %s", tc.column.Type, err, tc.wantErr)
+		}
+	}
+
+	out := runGenerated(t, src, `
+	for _, src := range []interface{}{[]byte("read,delete-all"), "write", "", nil, "execute", 42} {
+		var p Perms
+		err := p.Scan(src)
+		v, _ := p.Value()
+		fmt.Printf("%d %q %v\n", p, v, err)
+	}
+	v, _ := (PermsRead | PermsWrite).Value()
+	fmt.Printf("%q\n", v)`)
+
+	want := `5 "read,delete-all" <nil>
+2 "write" <nil>
+0 "" <nil>
+0 "" <nil>
+0 "" unknown Perms member "execute"
+0 "" cannot scan int into Perms
+"read,write"
+`
+	if out != want {
+		t.Errorf("generated bitmask type printed:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestFindUnmappedColumns(t *testing.T) {
+
+	descriptors := map[string][]TableDescriptor{
+		"places": {
+			{Field: "id", Type: "int", Null: "NO", Key: "PRI"},
+			{Field: "location", Type: "point", Null: "NO"},
+			{Field: "area", Type: "polygon", Null: "YES"},
+		},
+		"areas": {
+			{Field: "shape", Type: "geometry", Null: "NO"},
+		},
+		"users": {
+			{Field: "id", Type: "int", Null: "NO", Key: "PRI"},
+		},
+	}
+
+	tests := []struct {
+		name string
+		opts GenerateOptions
+		want []UnmappedColumn
+	}{
+		{
+			name: "default",
+			want: []UnmappedColumn{
+				{Table: "areas", Column: "shape", Type: "geometry"},
+				{Table: "places", Column: "location", Type: "point"},
+				{Table: "places", Column: "area", Type: "polygon"},
+			},
+		},
+		{
+			name: "custom types",
+			opts: GenerateOptions{CustomTypeMap: map[string]string{"POINT": "geo.Point", "POLYGON": "geo.Polygon"}},
+			want: []UnmappedColumn{{Table: "areas", Column: "shape", Type: "geometry"}},
+		},
+		{
+			name: "every type mapped",
+			opts: GenerateOptions{CustomTypeMap: map[string]string{"POINT": "[]byte", "POLYGON": "[]byte", "GEOMETRY": "[]byte"}},
+			want: []UnmappedColumn{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := FindUnmappedColumns(descriptors, tc.opts); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("FindUnmappedColumns() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsAutoIncrement(t *testing.T) {
+
+	tests := []struct {
+		extra string
+		want  bool
+	}{
+		{extra: "", want: false},
+		{extra: "auto_increment", want: true},
+		{extra: "AUTO_INCREMENT", want: true},
+		{extra: "DEFAULT_GENERATED on update CURRENT_TIMESTAMP", want: false},
+		{extra: "auto_increment INVISIBLE", want: true},
+	}
+
+	for _, tc := range tests {
+		if got := IsAutoIncrement(TableDescriptor{Field: "id", Extra: tc.extra}); got != tc.want {
+			t.Errorf("IsAutoIncrement(%q) = %t, want %t", tc.extra, got, tc.want)
+		}
+	}
+}
+
+func TestPrimaryKeys(t *testing.T) {
+
+	tests := []struct {
+		name string
+		tt   []TableDescriptor
+		want []string
+	}{
+		{name: "none", tt: []TableDescriptor{{Field: "a"}, {Field: "b", Key: "MUL"}}, want: []string{}},
+		{name: "single", tt: []TableDescriptor{{Field: "id", Key: "PRI"}, {Field: "name"}}, want: []string{"id"}},
+		{
+			name: "composite",
+			tt:   []TableDescriptor{{Field: "order_id", Key: "PRI"}, {Field: "qty"}, {Field: "line", Key: "PRI"}},
+			want: []string{"order_id", "line"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := PrimaryKeys(tc.tt); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("PrimaryKeys() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestColumnConstantsAndQueryHelpers(t *testing.T) {
+
+	descriptors := map[string][]TableDescriptor{
+		"order_lines": {
+			{Field: "order_id", Type: "bigint", Null: "NO", Key: "PRI"},
+			{Field: "line", Type: "int", Null: "NO", Key: "PRI"},
+			{Field: "sku", Type: "varchar(20)", Null: "NO"},
+			{Field: "qty", Type: "int", Null: "NO"},
+		},
+		"events": {
+			{Field: "id", Type: "bigint", Null: "NO", Key: "PRI", Extra: "auto_increment"},
+			{Field: "name", Type: "varchar(20)", Null: "NO"},
+		},
+	}
+	opts := GenerateOptions{WithColumnConstants: true, WithColumnsSlice: true, WithQueryHelpers: true}
+	src := generateFile(t, descriptors, opts)
+	checkSource(t, src)
+
+	out := runGenerated(t, src, `
+	fmt.Println(OrderLinesColOrderID, OrderLinesColLine, OrderLinesColSku, OrderLinesColQty)
+	fmt.Println(OrderLinesColumns, EventsColumns)
+	fmt.Println(OrderLinesSelectQuery)
+	fmt.Println(OrderLinesInsertQuery)
+	fmt.Println(OrderLinesUpdateQuery)
+	fmt.Println(OrderLinesDeleteQuery)
+	fmt.Println(EventsInsertQuery)
+	fmt.Println(EventsUpdateQuery)`)
+
+	want := "order_id line sku qty\n" +
+		"[order_id line sku qty] [id name]\n" +
+		"SELECT `order_id`, `line`, `sku`, `qty` FROM `order_lines`\n" +
+		"INSERT INTO `order_lines` (`order_id`, `line`, `sku`, `qty`) VALUES (?, ?, ?, ?)\n" +
+		"UPDATE `order_lines` SET `sku` = ?, `qty` = ? WHERE `order_id` = ? AND `line` = ?\n" +
+		"DELETE FROM `order_lines` WHERE `order_id` = ? AND `line` = ?\n" +
+		"INSERT INTO `events` (`name`) VALUES (?)\n" +
+		"UPDATE `events` SET `name` = ? WHERE `id` = ?\n"
+	if out != want {
+		t.Errorf("generated constants printed:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestFieldComments(t *testing.T) {
+
+	tt := []TableDescriptor{
+		{Field: "id", Type: "int", Null: "NO", Key: "PRI"},
+		{Field: "email", Type: "varchar(255)", Null: "NO", Comment: "Login address,\n  unique per account"},
+		{Field: "name", Type: "varchar(50)", Null: "NO"},
+	}
+
+	tests := []struct {
+		name string
+		opts GenerateOptions
+		want map[string]string
+	}{
+		{name: "disabled", want: map[string]string{}},
+		{name: "enabled", opts: GenerateOptions{WithFieldComments: true}, want: map[string]string{"Email": "Login address, unique per account\n"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			file := checkSource(t, generateFile(t, map[string][]TableDescriptor{"users": tt}, tc.opts))
+			got := make(map[string]string)
+			for _, f := range findStruct(t, file, "UsersData").Fields.List {
+				if f.Doc != nil {
+					got[f.Names[0].Name] = f.Doc.Text()
+				}
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("field comments = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStructTemplate(t *testing.T) {
+
+	tt := []TableDescriptor{
+		{Field: "id", Type: "int", Null: "NO", Key: "PRI"},
+		{Field: "name", Type: "varchar(50)", Null: "YES"},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "custom",
+			template: "type {{.Name}} struct { // {{.TableName}}\n{{range .Fields}}\t{{.Name}} {{.Type}} // {{.Column}}\n{{end}}}",
+			want:     "type UsersData struct { // users\n\tID int32 // id\n\tName *string // name\n}",
+		},
+		{
+			name:     "pad",
+			template: "{{range .Fields}}[{{pad .Name $.NameWidth}}|{{pad .Type $.TypeWidth}}]{{end}}",
+			want:     "[ID  |int32  ][Name|*string]",
+		},
+		{name: "invalid", template: "type {{.Name struct {}", wantErr: "failed parsing struct template"},
+		{name: "unknown field", template: "type {{.Missing}} struct {}", wantErr: "failed rendering struct UsersData"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := createStruct(tt, "users", GenerateOptions{StructTemplate: tc.template})
+			if tc.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tc.wantErr) {
+					t.Errorf("createStruct() = %v, want an error starting with %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("createStruct() = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("createStruct() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	out := strings.Builder{}
+	err := CreateAllTablesStructToWriter(&out, "models", map[string][]TableDescriptor{"users": tt}, GenerateOptions{StructTemplate: "{{"})
+	if err == nil || out.Len() > 0 {
+		t.Errorf("CreateAllTablesStructToWriter() = %v and wrote %q for an invalid template", err, out.String())
+	}
+}
+
+func TestGeneratedMethods(t *testing.T) {
+
+	descriptors := map[string][]TableDescriptor{
+		"users": {
+			{Field: "id", Type: "bigint", Null: "NO", Key: "PRI", Extra: "auto_increment"},
+			{Field: "name", Type: "varchar(50)", Null: "NO"},
+			{Field: "type", Type: "varchar(10)", Null: "NO"},
+			{Field: "nickname", Type: "varchar(50)", Null: "YES"},
+			{Field: "avatar", Type: "blob", Null: "YES"},
+		},
+		"tbl_settings": {
+			{Field: "id", Type: "int", Null: "NO", Key: "PRI", Extra: "auto_increment"},
+		},
+	}
+	opts := GenerateOptions{WithTableNameMethod: true, WithConstructors: true, WithStringer: true}
+	src := generateFile(t, descriptors, opts)
+	checkSource(t, src)
+
+	for _, want := range []string{"func NewUsersData(name string, type_ string) UsersData", "func NewTblSettingsData() TblSettingsData"} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated code does not contain %q:\n%s", want, src)
+		}
+	}
+
+	out := runGenerated(t, src, `
+	u := NewUsersData("ann", "admin")
+	fmt.Println(u.TableName(), TblSettingsData{}.TableName())
+	fmt.Println(u)
+	nickname := "annie"
+	u.ID, u.Nickname, u.Avatar = 7, &nickname, []byte{1, 2}
+	fmt.Println(u.String())
+	fmt.Println(NewTblSettingsData())`)
+
+	want := `users tbl_settings
+UsersData{ID=0 Name=ann Type=admin Nickname=<nil> Avatar=}
+UsersData{ID=7 Name=ann Type=admin Nickname=annie Avatar=0102}
+TblSettingsData{ID=0}
+`
+	if out != want {
+		t.Errorf("generated methods printed:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestGenerateStructsFormatted(t *testing.T) {
+
+	descriptors := map[string][]TableDescriptor{
+		"users": {
+			{Field: "id", Type: "bigint", Null: "NO", Key: "PRI"},
+			{Field: "created_at", Type: "datetime", Null: "NO", Comment: "creation time"},
+			{Field: "nickname", Type: "varchar(50)", Null: "YES"},
+		},
+		"orders": {
+			{Field: "id", Type: "bigint", Null: "NO", Key: "PRI"},
+			{Field: "total", Type: "decimal(10,2)", Null: "NO"},
+		},
+	}
+	opts := GenerateOptions{PackageName: "models", WithJSON: true, WithFieldComments: true, WithQueryHelpers: true, WithConstructors: true}
+
+	files, err := GenerateStructs(descriptors, opts)
+	if err != nil {
+		t.Fatalf("GenerateStructs() = %v", err)
+	}
+	if len(files) != len(descriptors) {
+		t.Fatalf("GenerateStructs() returned %d files, want %d", len(files), len(descriptors))
+	}
+
+	for table, src := range files {
+		formatted, err := format.Source([]byte(src))
+		if err != nil {
+			t.Fatalf("source of %s does not parse: %v\n%s", table, err, src)
+		}
+		if string(formatted) != src {
+			t.Errorf("source of %s is not gofmt formatted:\n%s\nwant:\n%s", table, src, formatted)
+		}
+		checkSource(t, src)
+	}
+
+	if _, err := GenerateStructs(descriptors, GenerateOptions{PackageName: "123"}); err == nil {
+		t.Error("GenerateStructs() accepted an invalid package name")
+	}
+}
+
+func TestValidatePackageName(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "models", wantErr: false},
+		{name: "db2go", wantErr: false},
+		{name: "_internal", wantErr: false},
+		{name: "modèles", wantErr: false},
+		{name: "", wantErr: true},
+		{name: "_", wantErr: true},
+		{name: "my-pkg", wantErr: true},
+		{name: "my pkg", wantErr: true},
+		{name: "123", wantErr: true},
+		{name: "type", wantErr: true},
+		{name: "package", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		if err := ValidatePackageName(tc.name); (err != nil) != tc.wantErr {
+			t.Errorf("ValidatePackageName(%q) = %v, want error %t", tc.name, err, tc.wantErr)
+		}
+	}
+}
@@ -1,12 +1,25 @@
 package db2go
 
-import "strings"
+import (
+	"strings"
+	"unicode"
+)
+
+// CommonInitialisms lists the words Camelize renders fully upper case, following the Go
+// naming convention (e.g. `UserID` rather than `UserId`).
+var CommonInitialisms = []string{
+	"ACL", "API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID", "HTML", "HTTP", "HTTPS",
+	"ID", "IP", "JSON", "LHS", "QPS", "RAM", "RHS", "RPC", "SLA", "SMTP", "SQL", "SSH",
+	"TCP", "TLS", "TTL", "UDP", "UI", "UID", "URI", "URL", "UTF8", "UUID", "VM", "XML",
+	"XMPP", "XSRF", "XSS",
+}
 
 // Camelize converts a snake_case string into a camelCase or PascalCase string.
 //
 // This function transforms an input string from snake_case to camelCase or PascalCase,
 // depending on the `capitalised` flag. Each underscore-separated word in the input string
-// is capitalized, and underscores are removed.
+// is capitalized, and underscores are removed. Words listed in `CommonInitialisms` are
+// upper cased entirely.
 //
 // Parameters:
 //   - input: string - The snake_case string to convert.
@@ -19,19 +32,369 @@ import "strings"
 // Example Usage:
 //   - Camelize("example_input", false) -> "exampleInput"
 //   - Camelize("example_input", true)  -> "ExampleInput"
+//   - Camelize("html_url", true)       -> "HTMLURL"
+//   - Camelize("api_key", false)       -> "apiKey"
 //
 // Notes:
-//   - If the input string is empty or contains no underscores, it is returned unchanged.
+//   - If the input string is empty or contains no underscores, it is returned unchanged,
+//     except for the capitalization of its first word.
+//   - Empty words produced by leading, trailing or repeated underscores are skipped, so
+//     `_x`, `x_` and `__x` all convert like `x`, and an input made only of underscores
+//     returns an empty string.
 //   - The function assumes the input string is in valid snake_case format.
 func Camelize(input string, capitalised bool) string {
-	words := strings.Split(input, "_")
+	return CamelizeWithInitialisms(input, capitalised, CommonInitialisms)
+}
+
+// CamelizeWithInitialisms behaves like `Camelize` with a custom list of initialisms.
+//
+// Parameters:
+//   - input: string - The snake_case string to convert.
+//   - capitalised: bool - A flag indicating whether the first letter of the resulting string
+//     should be capitalized (PascalCase) or lowercase (camelCase).
+//   - initialisms: []string - The words to upper case entirely. A nil list disables them.
+//
+// Returns:
+//   - string: The camelCase or PascalCase representation of the input string.
+//
+// Notes:
+//   - The first word of a camelCase result is never upper cased, so `id_card` stays `idCard`.
+func CamelizeWithInitialisms(input string, capitalised bool, initialisms []string) string {
+	words := make([]string, 0)
+	for _, w := range strings.Split(input, "_") {
+		if len(w) > 0 {
+			words = append(words, w)
+		}
+	}
+
 	for i := range words {
 
-		if i > 0 && len(words[i]) > 0 {
-			words[i] = strings.ToUpper(string(words[i][0])) + words[i][1:]
-		} else if capitalised && i == 0 && len(words[i]) > 0 {
+		if i == 0 && !capitalised {
+			continue
+		}
+
+		if isInitialism(words[i], initialisms) {
+			words[i] = strings.ToUpper(words[i])
+		} else {
 			words[i] = strings.ToUpper(string(words[i][0])) + words[i][1:]
 		}
 	}
 	return strings.Join(words, "")
 }
+
+// isInitialism reports whether word matches one of the initialisms, ignoring case.
+func isInitialism(word string, initialisms []string) bool {
+	for _, i := range initialisms {
+		if strings.EqualFold(word, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// goKeywords lists the reserved words of the Go language.
+var goKeywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true, "default": true,
+	"defer": true, "else": true, "fallthrough": true, "for": true, "func": true, "go": true,
+	"goto": true, "if": true, "import": true, "interface": true, "map": true, "package": true,
+	"range": true, "return": true, "select": true, "struct": true, "switch": true, "type": true,
+	"var": true,
+}
+
+// SanitizeIdentifier turns a camelized name into a valid Go identifier.
+//
+// This function is applied to every generated struct and field name, so columns whose
+// names are Go keywords or start with a digit still produce compiling code. Tags keep
+// the original column name.
+//
+// Parameters:
+//   - name: string - The name to sanitize, usually the result of `Camelize`.
+//
+// Returns:
+//   - string: A valid Go identifier.
+//
+// Example Usage:
+//   - SanitizeIdentifier("type")    -> "type_"
+//   - SanitizeIdentifier("range")   -> "range_"
+//   - SanitizeIdentifier("3dModel") -> "Field3dModel"
+//   - SanitizeIdentifier("")        -> "Field"
+//
+// Notes:
+//   - Characters that are not letters, digits or underscores are replaced by underscores.
+//   - The fields of columns named after a keyword can be renamed further with
+//     `GenerateOptions.KeywordFieldSuffix`.
+func SanitizeIdentifier(name string) string {
+
+	cleaned := strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name)
+
+	switch {
+	case cleaned == "":
+		return "Field"
+	case unicode.IsDigit([]rune(cleaned)[0]):
+		return "Field" + cleaned
+	case goKeywords[cleaned]:
+		return cleaned + "_"
+	}
+
+	return cleaned
+}
+
+// toWords replaces the characters of a column name that cannot appear in an identifier
+// with underscores, so they act as word separators when camelizing.
+func toWords(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// isIdentifier reports whether input only contains letters, digits and underscores
+// and does not start with a digit, so it can be camelized into a Go identifier.
+func isIdentifier(input string) bool {
+	if input == "" {
+		return false
+	}
+	for i, r := range input {
+		if r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// ToSnakeCase converts a camelCase or PascalCase string into snake_case.
+//
+// This function is the inverse of `Camelize`. A new word starts at each upper case letter
+// following a lower case letter or a digit, and at the last upper case letter of an
+// acronym followed by a lower case letter. Acronyms made of several words listed in
+// `CommonInitialisms` are split into those words.
+//
+// Parameters:
+//   - input: string - The camelCase or PascalCase string to convert.
+//
+// Returns:
+//   - string: The snake_case representation of the input string.
+//
+// Example Usage:
+//   - ToSnakeCase("UserID")       -> "user_id"
+//   - ToSnakeCase("HTTPServer")   -> "http_server"
+//   - ToSnakeCase("HTMLURL")      -> "html_url"
+//   - ToSnakeCase("userIDs")      -> "user_ids"
+//   - ToSnakeCase("Address2Line") -> "address2_line"
+//
+// Notes:
+//   - Existing underscores are kept, so snake_case input is returned unchanged.
+func ToSnakeCase(input string) string {
+	return ToSnakeCaseWithInitialisms(input, CommonInitialisms)
+}
+
+// ToSnakeCaseWithInitialisms behaves like `ToSnakeCase` with a custom list of initialisms.
+//
+// Parameters:
+//   - input: string - The camelCase or PascalCase string to convert.
+//   - initialisms: []string - The words recognized inside acronyms. A nil list disables them.
+//
+// Returns:
+//   - string: The snake_case representation of the input string.
+func ToSnakeCaseWithInitialisms(input string, initialisms []string) string {
+
+	result := strings.Builder{}
+	word := make([]rune, 0)
+	flush := func() {
+		result.WriteString(strings.ToLower(strings.Join(splitInitialisms(string(word), initialisms), "_")))
+		word = word[:0]
+	}
+
+	runes := []rune(input)
+	for i, r := range runes {
+
+		if r == '_' {
+			flush()
+			result.WriteRune(r)
+			continue
+		}
+
+		boundary := false
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			switch {
+			case unicode.IsLower(prev), unicode.IsDigit(prev):
+				boundary = true
+			case unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+				// The plural of an acronym, as in `IDs`, stays a single word
+				plural := runes[i+1] == 's' && (i+2 == len(runes) || !unicode.IsLower(runes[i+2]))
+				boundary = !(plural && isInitialism(string(word)+string(r), initialisms))
+			}
+		}
+
+		if boundary && len(word) > 0 {
+			flush()
+			result.WriteRune('_')
+		}
+		word = append(word, r)
+	}
+	flush()
+
+	return result.String()
+}
+
+// splitInitialisms splits an acronym made only of initialisms, like `HTMLURL`, into those
+// initialisms. Any other word is returned alone.
+func splitInitialisms(word string, initialisms []string) []string {
+
+	if word == "" || strings.ToUpper(word) != word {
+		return []string{word}
+	}
+
+	var split func(rest string) []string
+	split = func(rest string) []string {
+		if rest == "" {
+			return []string{}
+		}
+		for n := len(rest); n > 0; n-- {
+			if !isInitialism(rest[:n], initialisms) {
+				continue
+			}
+			if tail := split(rest[n:]); tail != nil {
+				return append([]string{rest[:n]}, tail...)
+			}
+		}
+		return nil
+	}
+
+	if parts := split(word); len(parts) > 1 {
+		return parts
+	}
+	return []string{word}
+}
+
+// irregularPlurals maps irregular English plurals to their singular form.
+var irregularPlurals = map[string]string{
+	"people":   "person",
+	"men":      "man",
+	"women":    "woman",
+	"children": "child",
+	"mice":     "mouse",
+	"geese":    "goose",
+	"teeth":    "tooth",
+	"feet":     "foot",
+	"oxen":     "ox",
+}
+
+// singularEndingInIe lists words whose plural ends in "ies" but whose singular ends in
+// "ie" rather than "y".
+var singularEndingInIe = map[string]bool{
+	"auntie": true, "brownie": true, "calorie": true, "cookie": true, "genie": true,
+	"hippie": true, "lie": true, "movie": true, "pie": true, "prairie": true,
+	"rookie": true, "selfie": true, "smoothie": true, "tie": true, "zombie": true,
+}
+
+// singularEndingInChe lists words whose plural ends in "ches" but whose singular ends in
+// "che" rather than "ch".
+var singularEndingInChe = map[string]bool{
+	"ache": true, "avalanche": true, "cache": true, "cliche": true, "headache": true,
+	"moustache": true, "mustache": true, "niche": true, "psyche": true, "quiche": true,
+}
+
+// singularEndingInUse lists words ending in "use" after a consonant, whose plural only
+// adds "s", unlike "bus" or "status".
+var singularEndingInUse = map[string]bool{
+	"abuse": true, "accuse": true, "excuse": true, "fuse": true, "misuse": true,
+	"muse": true, "refuse": true, "reuse": true, "ruse": true, "use": true,
+}
+
+// singularDoublingZ lists words ending in a single "z" whose plural doubles it, as in
+// "quizzes", unlike "buzzes".
+var singularDoublingZ = map[string]bool{"fez": true, "quiz": true, "whiz": true}
+
+// uncountableWords lists words whose plural is the same as their singular.
+var uncountableWords = map[string]bool{
+	"data": true, "equipment": true, "information": true, "metadata": true,
+	"news": true, "series": true, "sheep": true, "species": true,
+}
+
+// Singularize converts the last word of a snake_case name to its singular form.
+//
+// This function applies a small built-in ruleset covering the common English plural
+// forms, which is enough for the usual table names. It is used to derive struct names
+// like `User` from tables like `users`.
+//
+// Parameters:
+//   - input: string - The snake_case name to singularize.
+//
+// Returns:
+//   - string: The name with its last word in singular form.
+//
+// Example Usage:
+//   - Singularize("users")         -> "user"
+//   - Singularize("categories")    -> "category"
+//   - Singularize("boxes")         -> "box"
+//   - Singularize("people")        -> "person"
+//   - Singularize("order_details") -> "order_detail"
+//   - Singularize("movies")        -> "movie"
+//   - Singularize("warehouses")    -> "warehouse"
+//   - Singularize("caches")        -> "cache"
+//
+// Notes:
+//   - Words ending in "ss", "us" or "is" (e.g. "address", "status", "analysis") are
+//     considered singular already and are returned unchanged.
+//   - Plurals in "ies", "ches", "uses" and "zes" whose singular keeps the "e", like
+//     "cookies", "niches", "houses" or "sizes", are recognized from a built-in word list
+//     or from the letter before the ending.
+func Singularize(input string) string {
+
+	pos := strings.LastIndex(input, "_")
+	prefix, word := input[:pos+1], input[pos+1:]
+	lower := strings.ToLower(word)
+
+	if singular, ok := irregularPlurals[lower]; ok {
+		return prefix + word[:1] + singular[1:]
+	}
+
+	switch {
+	case uncountableWords[lower], len(lower) < 3:
+		return input
+	case strings.HasSuffix(lower, "ss"), strings.HasSuffix(lower, "us"), strings.HasSuffix(lower, "is"):
+		return input
+	case strings.HasSuffix(lower, "ies") && singularEndingInIe[lower[:len(lower)-1]]:
+		return prefix + word[:len(word)-1]
+	case strings.HasSuffix(lower, "ies"):
+		return prefix + word[:len(word)-3] + "y"
+	case strings.HasSuffix(lower, "ches") && singularEndingInChe[lower[:len(lower)-1]]:
+		return prefix + word[:len(word)-1]
+	case strings.HasSuffix(lower, "uses"):
+		// A vowel before "use" keeps the "e", as in "houses" and "causes"
+		if (len(lower) > 4 && isVowel(lower[len(lower)-5])) || singularEndingInUse[lower[:len(lower)-1]] {
+			return prefix + word[:len(word)-1]
+		}
+		return prefix + word[:len(word)-2]
+	case strings.HasSuffix(lower, "zzes") && singularDoublingZ[lower[:len(lower)-3]]:
+		return prefix + word[:len(word)-3]
+	case strings.HasSuffix(lower, "zes"):
+		// A vowel before "ze" keeps the "e", as in "sizes" and "prizes"
+		if len(lower) > 3 && isVowel(lower[len(lower)-4]) {
+			return prefix + word[:len(word)-1]
+		}
+		return prefix + word[:len(word)-2]
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"),
+		strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "shes"):
+		return prefix + word[:len(word)-2]
+	case strings.HasSuffix(lower, "s"):
+		return prefix + word[:len(word)-1]
+	}
+
+	return input
+}
+
+// isVowel reports whether a lower case ASCII letter is a vowel.
+func isVowel(c byte) bool {
+	return strings.IndexByte("aeiou", c) >= 0
+}
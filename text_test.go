@@ -0,0 +1,177 @@
+package db2go
+
+import "testing"
+
+func TestSingularize(t *testing.T) {
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"users", "user"},
+		{"categories", "category"},
+		{"people", "person"},
+		{"movies", "movie"},
+		{"cookies", "cookie"},
+		{"warehouses", "warehouse"},
+		{"houses", "house"},
+		{"caches", "cache"},
+		{"niches", "niche"},
+		{"headaches", "headache"},
+		{"ties", "tie"},
+		{"parties", "party"},
+		{"boxes", "box"},
+		{"churches", "church"},
+		{"dishes", "dish"},
+		{"addresses", "address"},
+		{"statuses", "status"},
+		{"buses", "bus"},
+		{"causes", "cause"},
+		{"uses", "use"},
+		{"excuses", "excuse"},
+		{"sizes", "size"},
+		{"waltzes", "waltz"},
+		{"quizzes", "quiz"},
+		{"buzzes", "buzz"},
+		{"status", "status"},
+		{"analysis", "analysis"},
+		{"news", "news"},
+		{"order_details", "order_detail"},
+		{"user_movies", "user_movie"},
+		{"Movies", "Movie"},
+		{"People", "Person"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			if got := Singularize(tc.input); got != tc.want {
+				t.Errorf("Singularize(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCamelize(t *testing.T) {
+
+	tests := []struct {
+		input       string
+		capitalised bool
+		want        string
+	}{
+		{"example_input", false, "exampleInput"},
+		{"example_input", true, "ExampleInput"},
+		{"user_id", true, "UserID"},
+		{"user_id", false, "userID"},
+		{"id", true, "ID"},
+		{"id_card", false, "idCard"},
+		{"html_url", true, "HTMLURL"},
+		{"api_key", false, "apiKey"},
+		{"http_status", true, "HTTPStatus"},
+		{"uuid", true, "UUID"},
+		{"name", true, "Name"},
+		{"name", false, "name"},
+		{"", true, ""},
+		{"_leading", true, "Leading"},
+		{"trailing_", true, "Trailing"},
+		{"double__underscore", true, "DoubleUnderscore"},
+		{"__x", false, "x"},
+		{"___", true, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			if got := Camelize(tc.input, tc.capitalised); got != tc.want {
+				t.Errorf("Camelize(%q, %v) = %q, want %q", tc.input, tc.capitalised, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCamelizeWithInitialisms(t *testing.T) {
+
+	tests := []struct {
+		input       string
+		initialisms []string
+		want        string
+	}{
+		{"user_id", nil, "UserId"},
+		{"user_id", []string{}, "UserId"},
+		{"sku_code", []string{"SKU"}, "SKUCode"},
+		{"user_id", []string{"SKU"}, "UserId"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			if got := CamelizeWithInitialisms(tc.input, true, tc.initialisms); got != tc.want {
+				t.Errorf("CamelizeWithInitialisms(%q, true, %v) = %q, want %q", tc.input, tc.initialisms, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeIdentifier(t *testing.T) {
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Name", "Name"},
+		{"type", "type_"},
+		{"range", "range_"},
+		{"func", "func_"},
+		{"Type", "Type"},
+		{"3dModel", "Field3dModel"},
+		{"2", "Field2"},
+		{"", "Field"},
+		{"user-name", "user_name"},
+		{"price$", "price_"},
+		{"Größe", "Größe"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			if got := SanitizeIdentifier(tc.input); got != tc.want {
+				t.Errorf("SanitizeIdentifier(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"UserID", "user_id"},
+		{"userID", "user_id"},
+		{"HTTPServer", "http_server"},
+		{"HTMLURL", "html_url"},
+		{"userIDs", "user_ids"},
+		{"Address2Line", "address2_line"},
+		{"Name", "name"},
+		{"already_snake", "already_snake"},
+		{"CreatedAt", "created_at"},
+		{"ID", "id"},
+		{"", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			if got := ToSnakeCase(tc.input); got != tc.want {
+				t.Errorf("ToSnakeCase(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestToSnakeCaseInvertsCamelize(t *testing.T) {
+
+	for _, input := range []string{"user_id", "html_url", "created_at", "api_key_hash", "order_line2"} {
+		t.Run(input, func(t *testing.T) {
+			if got := ToSnakeCase(Camelize(input, true)); got != input {
+				t.Errorf("ToSnakeCase(Camelize(%q)) = %q", input, got)
+			}
+		})
+	}
+}
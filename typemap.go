@@ -0,0 +1,115 @@
+package db2go
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// knownSQLTypes lists the base MySQL column types accepted as keys of a type map.
+var knownSQLTypes = map[string]bool{
+	"VARCHAR": true, "TEXT": true, "CHAR": true, "ENUM": true, "SET": true,
+	"LONGTEXT": true, "MEDIUMTEXT": true, "TINYTEXT": true,
+	"BIGINT": true, "INT": true, "INTEGER": true, "MEDIUMINT": true, "SMALLINT": true, "TINYINT": true,
+	"FLOAT": true, "DOUBLE": true, "REAL": true, "DECIMAL": true, "NUMERIC": true, "DEC": true, "FIXED": true,
+	"DATE": true, "DATETIME": true, "TIMESTAMP": true, "TIME": true, "YEAR": true,
+	"BLOB": true, "LONGBLOB": true, "MEDIUMBLOB": true, "TINYBLOB": true, "BINARY": true, "VARBINARY": true,
+	"BIT": true, "BOOL": true, "BOOLEAN": true, "JSON": true,
+	"GEOMETRY": true, "POINT": true, "LINESTRING": true, "POLYGON": true, "MULTIPOINT": true,
+	"MULTILINESTRING": true, "MULTIPOLYGON": true, "GEOMETRYCOLLECTION": true,
+}
+
+// LoadTypeMap reads a SQL-to-Go type mapping from a JSON file.
+//
+// The file must contain a single JSON object whose keys are base SQL types (without
+// length or `UNSIGNED`, e.g. "DECIMAL") and whose values are Go types (e.g. "string").
+// Keeping the mapping in a file lets it be adjusted without rebuilding the generator.
+//
+// Parameters:
+//   - path: string - The path of the JSON file to load.
+//
+// Returns:
+//   - map[string]string: The mapping, with keys normalized to upper case.
+//   - error: An error if the file cannot be read or parsed, if a key or value is empty,
+//     or if keys are not known SQL types. Unknown keys are all reported in one error.
+//
+// Notes:
+//   - The mapping is not applied on its own. Pass it to `GenerateOptions.MergeTypeMap`
+//     so the generator uses it on top of the built-in mapping.
+//
+// Example File:
+//
+//	{
+//	    "decimal": "string",
+//	    "json": "[]byte"
+//	}
+func LoadTypeMap(path string) (map[string]string, error) {
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading type map %s: %w", path, err)
+	}
+
+	raw := make(map[string]string)
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed parsing type map %s: %w", path, err)
+	}
+
+	result := make(map[string]string, len(raw))
+	unknown := make([]string, 0)
+	for k, v := range raw {
+		key := strings.ToUpper(strings.TrimSpace(k))
+		value := strings.TrimSpace(v)
+		if key == "" || value == "" {
+			return nil, fmt.Errorf("type map %s contains an empty entry (%q: %q)", path, k, v)
+		}
+		if !knownSQLTypes[key] {
+			unknown = append(unknown, k)
+			continue
+		}
+		result[key] = value
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("type map %s contains unknown SQL types: %s", path, strings.Join(unknown, ", "))
+	}
+
+	return result, nil
+}
+
+// MergeTypeMap returns a copy of the options whose `CustomTypeMap` also contains the
+// given mapping.
+//
+// This is how a mapping read by `LoadTypeMap` reaches the generator: its entries take
+// precedence over the built-in mapping, so columns of a mapped type get the Go type from
+// the file and every other column keeps its default type.
+//
+// Parameters:
+//   - typeMap: map[string]string - A mapping from base SQL types, upper cased and without
+//     length or `UNSIGNED`, to Go types, as returned by `LoadTypeMap`.
+//
+// Returns:
+//   - GenerateOptions: A copy of the options with the merged `CustomTypeMap`. Neither the
+//     receiver's map nor `typeMap` is modified.
+//
+// Notes:
+//   - Entries already in `CustomTypeMap` win over the ones in `typeMap`, so overrides set
+//     in code keep working when a mapping file is added.
+//   - Go types qualified by a package still need an entry in `CustomTypeImports`; see
+//     `Validate`.
+func (o GenerateOptions) MergeTypeMap(typeMap map[string]string) GenerateOptions {
+
+	merged := make(map[string]string, len(typeMap)+len(o.CustomTypeMap))
+	for k, v := range typeMap {
+		merged[k] = v
+	}
+	for k, v := range o.CustomTypeMap {
+		merged[k] = v
+	}
+	o.CustomTypeMap = merged
+
+	return o
+}
@@ -0,0 +1,109 @@
+package db2go
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTypeMap writes the content of a type map file to a temporary directory.
+func writeTypeMap(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "types.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadTypeMap(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			content: `{"decimal": "string", " Json ": "[]byte", "TINYINT": "int8"}`,
+			want:    map[string]string{"DECIMAL": "string", "JSON": "[]byte", "TINYINT": "int8"},
+		},
+		{
+			name:    "unknown SQL types",
+			content: `{"decimal": "string", "money": "int64", "bigserial": "int64"}`,
+			wantErr: "contains unknown SQL types: bigserial, money",
+		},
+		{
+			name:    "empty value",
+			content: `{"decimal": " "}`,
+			wantErr: "contains an empty entry",
+		},
+		{
+			name:    "malformed JSON",
+			content: `{"decimal": "string",}`,
+			wantErr: "failed parsing type map",
+		},
+		{
+			name:    "not an object",
+			content: `["decimal", "string"]`,
+			wantErr: "failed parsing type map",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := LoadTypeMap(writeTypeMap(t, tc.content))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("LoadTypeMap() = %v, want an error containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadTypeMap() = %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("LoadTypeMap() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := LoadTypeMap(filepath.Join(t.TempDir(), "missing.json")); err == nil || !strings.Contains(err.Error(), "failed reading type map") {
+		t.Errorf("LoadTypeMap() of a missing file = %v, want a read error", err)
+	}
+}
+
+func TestMergeTypeMap(t *testing.T) {
+
+	typeMap, err := LoadTypeMap(writeTypeMap(t, `{"decimal": "string", "tinyint": "int8"}`))
+	if err != nil {
+		t.Fatalf("LoadTypeMap() = %v", err)
+	}
+
+	opts := GenerateOptions{CustomTypeMap: map[string]string{"TINYINT": "uint8"}}
+	merged := opts.MergeTypeMap(typeMap)
+
+	want := map[string]string{"DECIMAL": "string", "TINYINT": "uint8"}
+	if !reflect.DeepEqual(merged.CustomTypeMap, want) {
+		t.Errorf("MergeTypeMap() = %v, want %v", merged.CustomTypeMap, want)
+	}
+	if len(opts.CustomTypeMap) != 1 || len(typeMap) != 2 {
+		t.Errorf("MergeTypeMap() modified its inputs: %v, %v", opts.CustomTypeMap, typeMap)
+	}
+
+	tt := []TableDescriptor{
+		{Field: "id", Type: "bigint", Null: "NO", Key: "PRI"},
+		{Field: "price", Type: "decimal(10,2)", Null: "NO"},
+		{Field: "flags", Type: "tinyint", Null: "NO"},
+	}
+	file := checkSource(t, "package models\n\n"+CreateStructWithOptions(tt, "products", merged))
+	fields := structFields(t, file, "ProductsData")
+	wantFields := map[string]string{"ID": "int64", "Price": "string", "Flags": "uint8"}
+	if !reflect.DeepEqual(fields, wantFields) {
+		t.Errorf("struct fields = %v, want %v", fields, wantFields)
+	}
+}